@@ -0,0 +1,146 @@
+// Package cache contains the caches used for storing the PDF data rendered by the server.
+package cache
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Cache is an interface used for storing the rendered PDF data by the query hash key.
+// The implementations needs to be safe for concurrent use.
+type Cache interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, data []byte)
+}
+
+// -------------------- MEMORY --------------------
+
+type memoryEntry struct {
+	key  string
+	data []byte
+}
+
+// Memory is an in-memory least recently used Cache.
+type Memory struct {
+	mu         sync.Mutex
+	maxEntries int
+	entries    map[string]*list.Element
+	order      *list.List
+}
+
+// NewMemory creates new in-memory LRU cache that holds up to maxEntries rendered documents.
+// If maxEntries is not positive the number of entries is not limited.
+func NewMemory(maxEntries int) *Memory {
+	return &Memory{maxEntries: maxEntries, entries: map[string]*list.Element{}, order: list.New()}
+}
+
+// Get implements Cache interface.
+func (m *Memory) Get(key string) ([]byte, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	elem, ok := m.entries[key]
+	if !ok {
+		return nil, false
+	}
+	m.order.MoveToFront(elem)
+	return elem.Value.(*memoryEntry).data, true
+}
+
+// Set implements Cache interface.
+func (m *Memory) Set(key string, data []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if elem, ok := m.entries[key]; ok {
+		elem.Value.(*memoryEntry).data = data
+		m.order.MoveToFront(elem)
+		return
+	}
+	m.entries[key] = m.order.PushFront(&memoryEntry{key: key, data: data})
+	for m.maxEntries > 0 && m.order.Len() > m.maxEntries {
+		last := m.order.Back()
+		m.order.Remove(last)
+		delete(m.entries, last.Value.(*memoryEntry).key)
+	}
+}
+
+// Len gets the number of cached entries.
+func (m *Memory) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.order.Len()
+}
+
+// -------------------- DISK --------------------
+
+// Disk is a Cache that stores the rendered documents as files in the directory. The keys that are not lowercase
+// hex strings, i.e. the query hashes, are hashed before they are used as the file names.
+type Disk struct {
+	dir string
+}
+
+// NewDisk creates new disk cache in the 'dir' directory. The directory is created if it doesn't exist.
+func NewDisk(dir string) (*Disk, error) {
+	if dir == "" {
+		return nil, errors.New("provided empty cache directory")
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &Disk{dir: dir}, nil
+}
+
+// Get implements Cache interface.
+func (d *Disk) Get(key string) ([]byte, bool) {
+	data, err := os.ReadFile(d.path(key))
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// Set implements Cache interface. The file is written atomically so that concurrent readers never see partial data.
+func (d *Disk) Set(key string, data []byte) {
+	f, err := os.CreateTemp(d.dir, d.fileName(key)+".*.tmp")
+	if err != nil {
+		return
+	}
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return
+	}
+	if err = os.Rename(f.Name(), d.path(key)); err != nil {
+		os.Remove(f.Name())
+	}
+}
+
+func (d *Disk) path(key string) string { return filepath.Join(d.dir, d.fileName(key)+".pdf") }
+
+// fileName gets the file name of the key, so that no key is able to point outside the cache directory.
+func (d *Disk) fileName(key string) string {
+	if isHex(key) {
+		return key
+	}
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func isHex(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
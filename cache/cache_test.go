@@ -0,0 +1,143 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMemoryEviction(t *testing.T) {
+	testCases := []struct {
+		name       string
+		maxEntries int
+		ops        []string // "set:key" or "get:key"
+		present    []string
+		missing    []string
+	}{
+		{
+			name:       "oldest evicted",
+			maxEntries: 2,
+			ops:        []string{"set:a", "set:b", "set:c"},
+			present:    []string{"b", "c"},
+			missing:    []string{"a"},
+		},
+		{
+			name:       "get refreshes entry",
+			maxEntries: 2,
+			ops:        []string{"set:a", "set:b", "get:a", "set:c"},
+			present:    []string{"a", "c"},
+			missing:    []string{"b"},
+		},
+		{
+			name:       "set refreshes entry",
+			maxEntries: 2,
+			ops:        []string{"set:a", "set:b", "set:a", "set:c"},
+			present:    []string{"a", "c"},
+			missing:    []string{"b"},
+		},
+		{
+			name:       "unlimited",
+			maxEntries: 0,
+			ops:        []string{"set:a", "set:b", "set:c"},
+			present:    []string{"a", "b", "c"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := NewMemory(tc.maxEntries)
+			for _, op := range tc.ops {
+				kind, key, _ := strings.Cut(op, ":")
+				if kind == "set" {
+					m.Set(key, []byte(key))
+				} else {
+					m.Get(key)
+				}
+			}
+			if m.Len() != len(tc.present) {
+				t.Errorf("Len() = %d, want %d", m.Len(), len(tc.present))
+			}
+			for _, key := range tc.present {
+				if data, ok := m.Get(key); !ok || string(data) != key {
+					t.Errorf("Get(%q) = %q, %v, want present", key, data, ok)
+				}
+			}
+			for _, key := range tc.missing {
+				if _, ok := m.Get(key); ok {
+					t.Errorf("Get(%q) found evicted entry", key)
+				}
+			}
+		})
+	}
+}
+
+func TestDisk(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "cache")
+	d, err := NewDisk(dir)
+	if err != nil {
+		t.Fatalf("NewDisk() failed: %v", err)
+	}
+
+	testCases := []struct {
+		name string
+		key  string
+	}{
+		{name: "query hash", key: "0123456789abcdef"},
+		{name: "parent traversal", key: "../../escaped"},
+		{name: "absolute path", key: filepath.Join(root, "absolute")},
+		{name: "upper case hex", key: "ABCDEF"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, ok := d.Get(tc.key); ok {
+				t.Fatalf("Get(%q) found an entry before Set", tc.key)
+			}
+			data := []byte("%PDF " + tc.key)
+			d.Set(tc.key, data)
+			got, ok := d.Get(tc.key)
+			if !ok || string(got) != string(data) {
+				t.Errorf("Get(%q) = %q, %v, want %q", tc.key, got, ok, data)
+			}
+			if path := d.path(tc.key); filepath.Dir(path) != dir {
+				t.Errorf("path(%q) = %q is outside of the cache directory", tc.key, path)
+			}
+		})
+	}
+
+	// Only the cache directory is created in the root and it contains the documents with no temporary files.
+	rootEntries, err := os.ReadDir(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rootEntries) != 1 {
+		t.Errorf("root directory has %d entries, want 1", len(rootEntries))
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if !strings.HasSuffix(e.Name(), ".pdf") {
+			t.Errorf("unexpected cache file %q", e.Name())
+		}
+	}
+	if len(entries) != len(testCases) {
+		t.Errorf("cache directory has %d entries, want %d", len(entries), len(testCases))
+	}
+
+	// Overwriting an entry replaces its data.
+	d.Set("abc", []byte("old"))
+	d.Set("abc", []byte("new"))
+	if got, _ := d.Get("abc"); string(got) != "new" {
+		t.Errorf("Get(abc) = %q after overwrite, want new", got)
+	}
+}
+
+func TestNewDiskEmptyDir(t *testing.T) {
+	if _, err := NewDisk(""); err == nil {
+		t.Error("NewDisk(\"\") expected an error")
+	}
+}
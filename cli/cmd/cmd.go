@@ -1,19 +1,25 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	homedir "github.com/mitchellh/go-homedir"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"github.com/unitechio/gohtml"
 	"github.com/unitechio/gohtml/client"
 	"github.com/unitechio/gohtml/content"
 	"github.com/unitechio/gohtml/sizes"
 	"github.com/unitechio/gopdf/common"
+	"github.com/unitechio/gopdf/model"
 )
 
 var cfgFile string
@@ -46,15 +52,30 @@ type parametersConfig struct {
 }
 
 type generateConfig struct {
-	Port   int    `mapstructure:"port"`
-	Host   string `mapstructure:"host"`
-	Https  bool   `mapstructure:"https"`
-	Prefix string `mapstructure:"prefix"`
+	Port                int           `mapstructure:"port"`
+	Host                string        `mapstructure:"host"`
+	Https               bool          `mapstructure:"https"`
+	Prefix              string        `mapstructure:"prefix"`
+	FailOnResourceError bool          `mapstructure:"fail-on-resource-error"`
+	Locale              string        `mapstructure:"locale"`
+	Timezone            string        `mapstructure:"timezone"`
+	DefaultFont         string        `mapstructure:"default-font"`
+	Stylesheet          string        `mapstructure:"stylesheet"`
+	UserPassword        string        `mapstructure:"user-password"`
+	OwnerPassword       string        `mapstructure:"owner-password"`
+	Encryption          string        `mapstructure:"encryption"`
+	NoPrint             bool          `mapstructure:"no-print"`
+	NoCopy              bool          `mapstructure:"no-copy"`
+	NoModify            bool          `mapstructure:"no-modify"`
+	PDFA                string        `mapstructure:"pdfa"`
+	Tagged              bool          `mapstructure:"tagged"`
+	Outline             bool          `mapstructure:"outline"`
+	Timeout             time.Duration `mapstructure:"timeout"`
 }
 
 var generateCmd = &cobra.Command{
 	Use:   "generate",
-	Short: "Generates PDF based on the provided HTML or directory with the HTML files.",
+	Short: "Generates PDF based on the provided HTML, Markdown or directory with the HTML files.",
 	Long: `A longer description that spans multiple lines and likely contains examples and usage of using your command. 
 			For example: Cobra is a CLI library for Go that empowers applications.
 			This application is a tool to generate the needed files
@@ -83,6 +104,21 @@ func init() {
 	generateCmd.Flags().String("host", "localhost", "Host name of the unihtml server")
 	generateCmd.Flags().BoolP("https", "s", false, "Protocol used in server communication")
 	generateCmd.Flags().StringP("prefix", "x", "", "Public api prefix used by the unihtml server")
+	generateCmd.Flags().Bool("fail-on-resource-error", false, "Fails the generation when any page resource failed to load")
+	generateCmd.Flags().String("locale", "", "BCP 47 locale emulated by the browser i.e. vi-VN")
+	generateCmd.Flags().String("timezone", "", "IANA timezone emulated by the browser i.e. Europe/Berlin")
+	generateCmd.Flags().String("default-font", "", "Default font family used by the browser")
+	generateCmd.Flags().String("stylesheet", "", "Print stylesheet file used for the Markdown input")
+	generateCmd.Flags().String("user-password", "", "Password required to open the output PDF")
+	generateCmd.Flags().String("owner-password", "", "Password granting the full access to the output PDF")
+	generateCmd.Flags().String("encryption", "aes-256", "Encryption algorithm of the password protected PDF: aes-128 or aes-256")
+	generateCmd.Flags().Bool("no-print", false, "Denies printing of the output PDF")
+	generateCmd.Flags().Bool("no-copy", false, "Denies copying the content of the output PDF")
+	generateCmd.Flags().Bool("no-modify", false, "Denies modifying the output PDF")
+	generateCmd.Flags().Bool("tagged", false, "Generates the tagged PDF with the structure tree from the HTML semantics")
+	generateCmd.Flags().Bool("outline", false, "Generates the PDF outline from the HTML headings")
+	generateCmd.Flags().String("pdfa", "", "PDF/A conformance level of the output PDF: 1b, 2b or 2u")
+	generateCmd.Flags().Duration("timeout", 15*time.Second, "Server timeout of the conversion, the client waits for the server error a few seconds longer")
 	generateCmd.Flags().Var(&paramsCfg.PaperWidth, "paper-width", "sets up the paper-width")
 	generateCmd.Flags().Var(&paramsCfg.PaperHeight, "paper-height", "sets up the paper-height")
 	generateCmd.Flags().Var(&paramsCfg.PageSize, "paper-size", "sets up the page size")
@@ -137,11 +173,42 @@ func runGenerate(cmd *cobra.Command, args []string) {
 		fmt.Printf("Err: %v", err)
 		os.Exit(1)
 	}
-	if !inputStat.IsDir() && filepath.Ext(inputStat.Name()) != ".html" {
-		fmt.Printf("Err: Currently only HTML files inputStat are supported. Input: %s", args[0])
+	isMarkdown := !inputStat.IsDir() && content.IsMarkdownFile(args[0])
+	if !inputStat.IsDir() && !isMarkdown && filepath.Ext(inputStat.Name()) != ".html" {
+		fmt.Printf("Err: Currently only HTML and Markdown files are supported. Input: %s", args[0])
 		os.Exit(1)
 	}
 
+	encryption, err := encryptionConfig()
+	if err != nil {
+		fmt.Printf("Err: %v", err)
+		os.Exit(1)
+	}
+	pdfaLevel, err := parsePDFA(generateCfg.PDFA)
+	if err != nil {
+		fmt.Printf("Err: %v", err)
+		os.Exit(1)
+	}
+	if pdfaLevel != 0 && encryption != nil {
+		fmt.Printf("Err: %v", gohtml.ErrPDFAEncrypted)
+		os.Exit(1)
+	}
+
+	if generateCfg.Timeout <= 0 {
+		fmt.Printf("Err: provided invalid timeout: %s", generateCfg.Timeout)
+		os.Exit(1)
+	}
+
+	var stylesheet string
+	if isMarkdown && generateCfg.Stylesheet != "" {
+		data, err := os.ReadFile(generateCfg.Stylesheet)
+		if err != nil {
+			fmt.Printf("Err: %v", err)
+			os.Exit(1)
+		}
+		stylesheet = string(data)
+	}
+
 	outputFile, err := os.OpenFile(args[1], os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0700)
 	if err != nil {
 		fmt.Printf("Err: %v", err)
@@ -156,13 +223,17 @@ func runGenerate(cmd *cobra.Command, args []string) {
 		Prefix:   generateCfg.Prefix,
 	})
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	// The client waits for the server timeout error on top of the server timeout.
+	ctx, cancel := context.WithTimeout(context.Background(), generateCfg.Timeout+client.ResponseTimeout)
 	defer cancel()
 
 	var contentObj content.Content
-	if inputStat.IsDir() {
+	switch {
+	case inputStat.IsDir():
 		contentObj, err = content.NewZipDirectory(args[0])
-	} else {
+	case isMarkdown:
+		contentObj, err = content.NewMarkdownFile(args[0], stylesheet)
+	default:
 		contentObj, err = content.NewHTMLFile(args[0])
 	}
 	if err != nil {
@@ -170,7 +241,7 @@ func runGenerate(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
-	query, err := client.BuildHTMLQuery().
+	builder := client.BuildHTMLQuery().
 		PaperWidth(paramsCfg.PaperWidth.Length).
 		PaperHeight(paramsCfg.PaperHeight.Length).
 		PageSize(paramsCfg.PageSize).
@@ -179,8 +250,21 @@ func runGenerate(cmd *cobra.Command, args []string) {
 		MarginLeft(paramsCfg.MarginLeft.Length).
 		MarginRight(paramsCfg.MarginRight.Length).
 		Orientation(paramsCfg.Orientation).
-		SetContent(contentObj).
-		Query()
+		Locale(generateCfg.Locale).
+		Timezone(generateCfg.Timezone).
+		DefaultFontFamily(generateCfg.DefaultFont).
+		TimeoutDuration(generateCfg.Timeout).
+		SetContent(contentObj)
+	if generateCfg.FailOnResourceError {
+		builder.FailOnResourceError()
+	}
+	if generateCfg.Tagged {
+		builder.TaggedPDF()
+	}
+	if generateCfg.Outline {
+		builder.DocumentOutline()
+	}
+	query, err := builder.Query()
 	if err != nil {
 		fmt.Printf("Err: %v", err)
 		os.Exit(1)
@@ -188,14 +272,25 @@ func runGenerate(cmd *cobra.Command, args []string) {
 
 	resp, err := clientObj.ConvertHTML(ctx, query)
 	if err != nil {
+		var diagErr *client.DiagnosticsError
+		if errors.As(err, &diagErr) {
+			printDiagnostics(diagErr.Diagnostics)
+		}
 		fmt.Printf("Err: %v", err)
 		os.Exit(1)
 	}
+	printDiagnostics(resp.Diagnostics)
 
 	common.Log.Trace("Executing generate query taken: %s", time.Since(start))
 	start = time.Now()
 
-	if _, err = outputFile.Write(resp.Data); err != nil {
+	output := outputConfig{
+		pdfa:       pdfaLevel,
+		encryption: encryption,
+		tagged:     generateCfg.Tagged,
+		lang:       generateCfg.Locale,
+	}
+	if err = writeOutput(outputFile, resp.Data, output); err != nil {
 		fmt.Printf("Err: %v", err)
 		os.Exit(1)
 	}
@@ -204,6 +299,124 @@ func runGenerate(cmd *cobra.Command, args []string) {
 	fmt.Printf("Generated with success in %s", time.Since(start))
 }
 
+// encryptionConfig gets the encryption of the output PDF, or nil if no protection flags were set.
+func encryptionConfig() (*gohtml.Encryption, error) {
+	enc := gohtml.Encryption{
+		UserPassword:  generateCfg.UserPassword,
+		OwnerPassword: generateCfg.OwnerPassword,
+	}
+	for _, deny := range []struct {
+		set        bool
+		permission gohtml.Permission
+	}{
+		{generateCfg.NoPrint, gohtml.PermissionPrint},
+		{generateCfg.NoCopy, gohtml.PermissionCopy},
+		{generateCfg.NoModify, gohtml.PermissionModify | gohtml.PermissionAssemble},
+	} {
+		if deny.set {
+			enc.Deny |= deny.permission
+		}
+	}
+	if enc.UserPassword == "" && enc.OwnerPassword == "" && enc.Deny == 0 {
+		return nil, nil
+	}
+	switch generateCfg.Encryption {
+	case "aes-256", "":
+		enc.Algorithm = gohtml.AES256
+	case "aes-128":
+		enc.Algorithm = gohtml.AES128
+	default:
+		return nil, fmt.Errorf("unsupported encryption algorithm: %s", generateCfg.Encryption)
+	}
+	if err := enc.Validate(); err != nil {
+		return nil, err
+	}
+	return &enc, nil
+}
+
+// parsePDFA parses the PDF/A conformance level flag, i.e. 2b. Empty level is the zero PDFA.
+func parsePDFA(level string) (gohtml.PDFA, error) {
+	switch strings.ToLower(level) {
+	case "":
+		return 0, nil
+	case "1b":
+		return gohtml.PDFA1B, nil
+	case "2b":
+		return gohtml.PDFA2B, nil
+	case "2u":
+		return gohtml.PDFA2U, nil
+	}
+	return 0, fmt.Errorf("unsupported PDF/A conformance level: %s", level)
+}
+
+// outputConfig are the options of rewriting the generated PDF.
+type outputConfig struct {
+	pdfa       gohtml.PDFA
+	encryption *gohtml.Encryption
+
+	// tagged keeps the structure tree of the tagged PDF in the rewritten document, lang is its natural language.
+	tagged bool
+	lang   string
+}
+
+// writeOutput writes the generated PDF, rewriting it to the PDF/A level or with the password protection if set.
+func writeOutput(w io.Writer, data []byte, cfg outputConfig) error {
+	if cfg.pdfa == 0 && cfg.encryption == nil {
+		_, err := w.Write(data)
+		return err
+	}
+	reader, err := model.NewPdfReader(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	pages, err := reader.GetNumPages()
+	if err != nil {
+		return err
+	}
+	writer := model.NewPdfWriter()
+	if cfg.tagged {
+		writer.SetOptimizer(gohtml.NewStructTreeOptimizer(reader, cfg.lang))
+	}
+	if outline := reader.GetOutlineTree(); outline != nil {
+		writer.AddOutlineTree(outline)
+	}
+	for i := 1; i <= pages; i++ {
+		page, err := reader.GetPage(i)
+		if err != nil {
+			return err
+		}
+		if err = writer.AddPage(page); err != nil {
+			return err
+		}
+	}
+	if cfg.pdfa != 0 {
+		if err = cfg.pdfa.Apply(&writer); err != nil {
+			return err
+		}
+	}
+	if cfg.encryption != nil {
+		if err = cfg.encryption.Apply(&writer); err != nil {
+			return err
+		}
+	}
+	return writer.Write(w)
+}
+
+func printDiagnostics(d *client.Diagnostics) {
+	if d == nil {
+		return
+	}
+	for _, msg := range d.Console {
+		fmt.Printf("Console %s: %s\n", msg.Level, msg.Text)
+	}
+	for _, exc := range d.Exceptions {
+		fmt.Printf("Exception: %s (%s:%d:%d)\n", exc.Message, exc.URL, exc.Line, exc.Column)
+	}
+	for _, res := range d.FailedResources {
+		fmt.Printf("Failed resource: %s [%d] %s\n", res.URL, res.Status, res.Error)
+	}
+}
+
 func setupLogging() {
 	level := common.LogLevelInfo
 	if debug {
@@ -0,0 +1,190 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/unitechio/gohtml"
+	"github.com/unitechio/gohtml/sizes"
+)
+
+var (
+	signCfg   = signConfig{}
+	signField = signFieldConfig{}
+)
+
+type signConfig struct {
+	PKCS12         string `mapstructure:"p12"`
+	PKCS12Password string `mapstructure:"p12-password"`
+	Key            string `mapstructure:"key"`
+	Cert           string `mapstructure:"cert"`
+	Name           string `mapstructure:"name"`
+	Reason         string `mapstructure:"reason"`
+	Location       string `mapstructure:"location"`
+	Page           int    `mapstructure:"page"`
+	TSA            string `mapstructure:"tsa"`
+}
+
+type signFieldConfig struct {
+	X      sizes.LengthFlag
+	Y      sizes.LengthFlag
+	Width  sizes.LengthFlag
+	Height sizes.LengthFlag
+}
+
+var signCmd = &cobra.Command{
+	Use:        "sign",
+	Short:      "Digitally signs the PDF with the PKCS#12 or PEM key and certificate.",
+	Run:        runSign,
+	Args:       cobra.ExactArgs(2),
+	ArgAliases: []string{"input-pdf", "output-pdf"},
+	Example:    "sign input.pdf output.pdf --p12 signer.p12 --p12-password secret --width 60mm --height 20mm",
+}
+
+func init() {
+	rootCmd.AddCommand(signCmd)
+	signCmd.Flags().String("p12", "", "PKCS#12 file with the signing key and certificate")
+	signCmd.Flags().String("p12-password", "", "Password of the PKCS#12 file")
+	signCmd.Flags().String("key", "", "PEM file with the signing private key")
+	signCmd.Flags().String("cert", "", "PEM file with the signing certificate")
+	signCmd.Flags().String("name", "", "Name of the signer")
+	signCmd.Flags().String("reason", "", "Reason of the signature")
+	signCmd.Flags().String("location", "", "Location of the signer")
+	signCmd.Flags().Int("page", 1, "Page number of the visible signature field")
+	signCmd.Flags().String("tsa", "", "URL of the timestamp authority used to timestamp the signed PDF")
+	signCmd.Flags().Var(&signField.X, "x", "sets up the left position of the visible signature field")
+	signCmd.Flags().Var(&signField.Y, "y", "sets up the top position of the visible signature field")
+	signCmd.Flags().Var(&signField.Width, "width", "sets up the width of the visible signature field")
+	signCmd.Flags().Var(&signField.Height, "height", "sets up the height of the visible signature field")
+}
+
+func runSign(cmd *cobra.Command, args []string) {
+	start := time.Now()
+
+	if err := viper.BindPFlags(cmd.Flags()); err != nil {
+		fmt.Printf("Err: %v", err)
+		os.Exit(1)
+	}
+	if err := viper.Unmarshal(&signCfg); err != nil {
+		fmt.Printf("Err: %v", err)
+		os.Exit(1)
+	}
+
+	setupLogging()
+
+	signature, err := signatureConfig()
+	if err != nil {
+		fmt.Printf("Err: %v", err)
+		os.Exit(1)
+	}
+
+	input, err := os.ReadFile(args[0])
+	if err != nil {
+		fmt.Printf("Err: %v", err)
+		os.Exit(1)
+	}
+
+	// The signed PDF is written once the input is read, so that the output can replace the input file.
+	output := bytes.Buffer{}
+	if err = gohtml.SignPDF(&output, bytes.NewReader(input), *signature); err != nil {
+		fmt.Printf("Err: %v", err)
+		os.Exit(1)
+	}
+	if err = writeFileAtomic(args[1], output.Bytes()); err != nil {
+		fmt.Printf("Err: %v", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Signed with success in %s", time.Since(start))
+}
+
+// signatureConfig gets the signature with the signer loaded from the PKCS#12 or the PEM files.
+func signatureConfig() (*gohtml.Signature, error) {
+	var signer *gohtml.Signer
+	switch {
+	case signCfg.PKCS12 != "":
+		data, err := os.ReadFile(signCfg.PKCS12)
+		if err != nil {
+			return nil, err
+		}
+		if signer, err = gohtml.NewSignerFromPKCS12(data, signCfg.PKCS12Password); err != nil {
+			return nil, err
+		}
+	case signCfg.Key != "" && signCfg.Cert != "":
+		keyPEM, err := os.ReadFile(signCfg.Key)
+		if err != nil {
+			return nil, err
+		}
+		certPEM, err := os.ReadFile(signCfg.Cert)
+		if err != nil {
+			return nil, err
+		}
+		if signer, err = gohtml.NewSignerFromPEM(keyPEM, certPEM); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.New("either the --p12 or both the --key and --cert files are required")
+	}
+
+	signature := &gohtml.Signature{
+		Signer:   *signer,
+		Name:     signCfg.Name,
+		Reason:   signCfg.Reason,
+		Location: signCfg.Location,
+	}
+	if signField.Width.Length != nil || signField.Height.Length != nil {
+		signature.Field = &gohtml.SignatureField{
+			Page:   signCfg.Page,
+			X:      lengthOrZero(signField.X),
+			Y:      lengthOrZero(signField.Y),
+			Width:  signField.Width.Length,
+			Height: signField.Height.Length,
+		}
+	}
+	if signCfg.TSA != "" {
+		signature.Timestamp = &gohtml.Timestamp{
+			ServerURL: signCfg.TSA,
+			Client:    &http.Client{Timeout: 10 * time.Second},
+		}
+	}
+	if err := signature.Validate(); err != nil {
+		return nil, err
+	}
+	return signature, nil
+}
+
+func lengthOrZero(lf sizes.LengthFlag) sizes.Length {
+	if lf.Length == nil {
+		return sizes.Millimeter(0)
+	}
+	return lf.Length
+}
+
+// writeFileAtomic writes the data into the temporary file next to the path and renames it to the path,
+// so that the existing file is replaced only once the data is written.
+func writeFileAtomic(path string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/unitechio/gohtml"
+)
+
+var validateCmd = &cobra.Command{
+	Use:        "validate",
+	Short:      "Validates the PDF/A conformance of the PDF and reports the violated rules.",
+	Run:        runValidate,
+	Args:       cobra.ExactArgs(1),
+	ArgAliases: []string{"input-pdf"},
+	Example:    "validate invoice.pdf --pdfa 2b",
+}
+
+func init() {
+	rootCmd.AddCommand(validateCmd)
+	validateCmd.Flags().String("pdfa", "2b", "PDF/A conformance level: 1b, 2b or 2u")
+}
+
+func runValidate(cmd *cobra.Command, args []string) {
+	setupLogging()
+
+	flag, err := cmd.Flags().GetString("pdfa")
+	if err != nil {
+		fmt.Printf("Err: %v", err)
+		os.Exit(1)
+	}
+	level, err := parsePDFA(flag)
+	if err == nil && level == 0 {
+		err = fmt.Errorf("PDF/A conformance level not defined")
+	}
+	if err != nil {
+		fmt.Printf("Err: %v", err)
+		os.Exit(1)
+	}
+
+	inputFile, err := os.Open(args[0])
+	if err != nil {
+		fmt.Printf("Err: %v", err)
+		os.Exit(1)
+	}
+	defer inputFile.Close()
+
+	violations, err := gohtml.ValidatePDFA(inputFile, level)
+	if err != nil {
+		fmt.Printf("Err: %v", err)
+		os.Exit(1)
+	}
+	if len(violations) == 0 {
+		fmt.Printf("%s conforms to %s\n", args[0], level)
+		return
+	}
+	fmt.Printf("%s doesn't conform to %s:\n", args[0], level)
+	for _, v := range violations {
+		fmt.Printf("  %s: %s\n", v.Rule, v.Detail)
+	}
+	os.Exit(2)
+}
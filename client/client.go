@@ -7,21 +7,29 @@ import (
 	"compress/flate"
 	"compress/gzip"
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"mime"
+	"mime/multipart"
 	"net"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
 	"time"
+	// The time zone database is embedded so that the Timezone is validated on the hosts without zoneinfo.
+	_ "time/tzdata"
 
 	"github.com/unitechio/gohtml/content"
 	"github.com/unitechio/gohtml/selector"
 	"github.com/unitechio/gohtml/sizes"
 	"github.com/unitechio/gopdf/common"
+	"golang.org/x/text/language"
 )
 
 // Options are the client options used by the HTTP client.
@@ -99,8 +107,13 @@ func (q *QueryBuilder) Query() (*Query, error) {
 	return &q.query, nil
 }
 
+// ResponseTimeout is the time the HTTP client waits on top of the query timeout, so that the server
+// is able to respond with its own timeout error.
+const ResponseTimeout = 5 * time.Second
+
 // TimeoutDuration sets the server query duration timeout.
-// Once the timeout is reached the server will return an error.
+// Once the timeout is reached the server will return an error. The HTTP client waits
+// for the response the timeout duration extended by a few seconds.
 func (qb *QueryBuilder) TimeoutDuration(d time.Duration) *QueryBuilder {
 	qb.query.TimeoutDuration = d
 	return qb
@@ -229,8 +242,159 @@ func (q *QueryBuilder) Validate() error {
 
 // PDFResponse is the response used by the HTMLConverter.
 type PDFResponse struct {
-	ID   string `json:"id"`
-	Data []byte `json:"data"`
+	ID          string       `json:"id"`
+	Data        []byte       `json:"data"`
+	Diagnostics *Diagnostics `json:"diagnostics,omitempty"`
+}
+
+// Diagnostics are the browser messages and failures collected by the server while rendering the page.
+type Diagnostics struct {
+	// Console are the console errors and warnings logged by the page.
+	Console []ConsoleMessage `json:"console,omitempty"`
+
+	// Exceptions are the uncaught exceptions thrown by the page scripts.
+	Exceptions []Exception `json:"exceptions,omitempty"`
+
+	// FailedResources are the sub-resources that failed to load or returned a non-2xx status.
+	FailedResources []FailedResource `json:"failedResources,omitempty"`
+
+	// MainStatus is the HTTP status code of the main document for the "web" content.
+	MainStatus int `json:"mainStatus,omitempty"`
+}
+
+// ConsoleMessage is a single browser console entry.
+type ConsoleMessage struct {
+	Level string `json:"level"`
+	Text  string `json:"text"`
+	URL   string `json:"url,omitempty"`
+	Line  int    `json:"line,omitempty"`
+}
+
+// Exception is an uncaught exception thrown by the page.
+type Exception struct {
+	Message string `json:"message"`
+	URL     string `json:"url,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+}
+
+// FailedResource is a sub-resource that the browser failed to load.
+type FailedResource struct {
+	URL    string `json:"url"`
+	Status int    `json:"status,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// HasErrors checks if any console error, exception or failed resource was reported.
+func (d *Diagnostics) HasErrors() bool {
+	if d == nil {
+		return false
+	}
+	if len(d.Exceptions) > 0 || len(d.FailedResources) > 0 {
+		return true
+	}
+	for _, msg := range d.Console {
+		if msg.Level == "error" {
+			return true
+		}
+	}
+	return false
+}
+
+// DiagnosticsError is an error returned when the rendering fails due to the collected diagnostics.
+type DiagnosticsError struct {
+	Err         error
+	Diagnostics *Diagnostics
+}
+
+// Error implements error interface.
+func (e *DiagnosticsError) Error() string { return e.Err.Error() }
+
+// Unwrap gets the underlying error.
+func (e *DiagnosticsError) Unwrap() error { return e.Err }
+
+// diagnosticsHeader is the response header with base64 encoded JSON Diagnostics. It's only read from the servers
+// that don't respond with the multipart body, as many failed resources may exceed the response header limits.
+const diagnosticsHeader = "X-Diagnostics"
+
+// acceptHeader is the request Accept header value. The server responds with the multipart/mixed body
+// carrying the PDF data part and the JSON Diagnostics part when the diagnostics were collected.
+const acceptHeader = "application/pdf, multipart/mixed"
+
+func parseDiagnostics(header http.Header) (*Diagnostics, error) {
+	raw := header.Get(diagnosticsHeader)
+	if raw == "" {
+		return nil, nil
+	}
+	data, err := base64.StdEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, fmt.Errorf("decoding diagnostics failed: %w", err)
+	}
+	return decodeDiagnostics(bytes.NewReader(data))
+}
+
+func decodeDiagnostics(r io.Reader) (*Diagnostics, error) {
+	d := &Diagnostics{}
+	if err := json.NewDecoder(r).Decode(d); err != nil {
+		return nil, fmt.Errorf("decoding diagnostics failed: %w", err)
+	}
+	return d, nil
+}
+
+// readResponseBody reads the response data and its diagnostics. The multipart/mixed body holds the data part
+// and the "application/json" diagnostics part, any other body is the data itself with the diagnostics
+// taken from the response header.
+func readResponseBody(r io.Reader, header http.Header) ([]byte, *Diagnostics, error) {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/mixed" {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return data, nil, err
+		}
+		diagnostics, err := parseDiagnostics(header)
+		if err != nil {
+			common.Log.Debug("Parsing response diagnostics failed: %v", err)
+		}
+		return data, diagnostics, nil
+	}
+
+	var (
+		data        []byte
+		diagnostics *Diagnostics
+	)
+	mr := multipart.NewReader(r, params["boundary"])
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return data, diagnostics, nil
+		}
+		if err != nil {
+			return data, diagnostics, err
+		}
+		partType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		if partType == "application/json" {
+			if diagnostics, err = decodeDiagnostics(part); err != nil {
+				common.Log.Debug("Parsing response diagnostics failed: %v", err)
+			}
+			continue
+		}
+		if data, err = io.ReadAll(part); err != nil {
+			return data, diagnostics, err
+		}
+	}
+}
+
+func (rp *RenderParameters) checkDiagnostics(d *Diagnostics) error {
+	if d == nil {
+		return nil
+	}
+	if rp.FailOnHTTPError && d.MainStatus != 0 && (d.MainStatus < 200 || d.MainStatus > 299) {
+		return &DiagnosticsError{Err: fmt.Errorf("%w: %d", ErrMainResourceStatus, d.MainStatus), Diagnostics: d}
+	}
+	if rp.FailOnResourceError && len(d.FailedResources) > 0 {
+		return &DiagnosticsError{Err: fmt.Errorf("%w: %s", ErrResourceFailed, d.FailedResources[0].URL), Diagnostics: d}
+	}
+	return nil
 }
 
 // MarginLeft sets up the MarginLeft parameter for the query.
@@ -241,9 +405,95 @@ func (q *QueryBuilder) MarginLeft(marginLeft sizes.Length) *QueryBuilder {
 
 // RenderParameters are the parameters related with the rendering.
 type RenderParameters struct {
-	WaitTime    time.Duration `schema:"minimum-load-time" json:"waitTime"`
-	WaitReady   []BySelector  `json:"waitReady"`
-	WaitVisible []BySelector  `json:"waitVisible"`
+	WaitTime     time.Duration `schema:"minimum-load-time" json:"waitTime"`
+	WaitReady    []BySelector  `json:"waitReady"`
+	WaitVisible  []BySelector  `json:"waitVisible"`
+	WaitFunction *WaitFunction `json:"waitFunction,omitempty"`
+
+	// WaitNetworkIdle is the duration without any network activity the page needs to reach before rendering.
+	WaitNetworkIdle time.Duration `json:"waitNetworkIdle,omitempty"`
+
+	// WaitHidden are the selectors that needs to become hidden before rendering.
+	WaitHidden []BySelector `json:"waitHidden,omitempty"`
+
+	// WaitNotPresent are the selectors that needs to be removed from the DOM before rendering.
+	WaitNotPresent []BySelector `json:"waitNotPresent,omitempty"`
+
+	// InjectCSS are the stylesheets injected into the page after it's loaded and before the wait conditions.
+	InjectCSS []Injection `json:"injectCSS,omitempty"`
+
+	// InjectJS are the scripts executed on the page after it's loaded and before the wait conditions.
+	InjectJS []Injection `json:"injectJS,omitempty"`
+
+	// DisableJavaScript disables JavaScript execution for the rendered page.
+	DisableJavaScript bool `json:"disableJavaScript,omitempty"`
+
+	// FailOnResourceError fails the conversion when any of the page sub-resources failed to load.
+	FailOnResourceError bool `json:"failOnResourceError,omitempty"`
+
+	// FailOnHTTPError fails the conversion when the main "web" URL returns a non-2xx status.
+	FailOnHTTPError bool `json:"failOnHttpError,omitempty"`
+
+	// Locale is the BCP 47 language tag emulated by the browser, i.e. "vi-VN".
+	Locale string `json:"locale,omitempty"`
+
+	// Timezone is the IANA time zone name emulated by the browser, i.e. "Europe/Berlin".
+	Timezone string `json:"timezone,omitempty"`
+
+	// DefaultFontFamily is the font family used for the text with no font family defined.
+	DefaultFontFamily string `json:"defaultFontFamily,omitempty"`
+
+	// TaggedPDF generates the PDF structure tree from the HTML semantics, i.e. headings, paragraphs, lists,
+	// tables and figures with their alt text, in the reading order of the page.
+	TaggedPDF bool `json:"taggedPdf,omitempty"`
+
+	// DocumentOutline embeds the PDF outline generated from the page headings. It requires the TaggedPDF.
+	DocumentOutline bool `json:"documentOutline,omitempty"`
+}
+
+// Injection is a script or a stylesheet injected into the rendered page. It is either an inline Content
+// or a Path to the file within the content bundle.
+type Injection struct {
+	Content string `json:"content,omitempty"`
+	Path    string `json:"path,omitempty"`
+}
+
+// Validate checks validity of the Injection.
+func (i Injection) Validate() error {
+	if i.Content == "" && i.Path == "" {
+		return errors.New("provided empty injection")
+	}
+	if i.Content != "" && i.Path != "" {
+		return errors.New("injection can't define both content and path")
+	}
+	return nil
+}
+
+// WaitFunction is a JavaScript expression that is polled by the server until it evaluates to a truthy value.
+type WaitFunction struct {
+	Expression   string        `json:"expression"`
+	PollInterval time.Duration `json:"pollInterval"`
+	Timeout      time.Duration `json:"timeout"`
+}
+
+// Validate checks validity of the WaitFunction.
+func (w *WaitFunction) Validate() error {
+	if strings.TrimSpace(w.Expression) == "" {
+		return errors.New("provided empty wait function expression")
+	}
+	if w.PollInterval <= 0 {
+		return errors.New("wait function poll interval must be positive")
+	}
+	if w.Timeout <= 0 {
+		return errors.New("wait function timeout must be positive")
+	}
+	if w.PollInterval > w.Timeout {
+		return errors.New("wait function poll interval exceeds its timeout")
+	}
+	if w.Timeout > maxWaitTime {
+		return errors.New("too long wait function timeout. Maximum is 3 minutes")
+	}
+	return nil
 }
 
 // WaitReady waits for the selector to get ready - 'loaded'.
@@ -258,9 +508,12 @@ func (q *QueryBuilder) PaperHeight(paperHeight sizes.Length) *QueryBuilder {
 	return q
 }
 
+// maxWaitTime is the maximum time the server is allowed to wait for the page.
+const maxWaitTime = time.Minute * 3
+
 // Validate checks the validity of the RenderParameters.
 func (rp *RenderParameters) Validate() error {
-	if rp.WaitTime > time.Minute*3 {
+	if rp.WaitTime > maxWaitTime {
 		return errors.New("too long minimum load time. Maximum is 3 minutes")
 	}
 	for _, _de := range rp.WaitReady {
@@ -268,6 +521,68 @@ func (rp *RenderParameters) Validate() error {
 			return fmt.Errorf("one of wait ready selector is not valid: %w", _cgf)
 		}
 	}
+	for _, sel := range rp.WaitVisible {
+		if err := sel.Validate(); err != nil {
+			return fmt.Errorf("one of wait visible selector is not valid: %w", err)
+		}
+	}
+	for _, sel := range rp.WaitHidden {
+		if err := sel.Validate(); err != nil {
+			return fmt.Errorf("one of wait hidden selector is not valid: %w", err)
+		}
+	}
+	for _, sel := range rp.WaitNotPresent {
+		if err := sel.Validate(); err != nil {
+			return fmt.Errorf("one of wait not present selector is not valid: %w", err)
+		}
+	}
+	for _, inj := range rp.InjectCSS {
+		if err := inj.Validate(); err != nil {
+			return fmt.Errorf("one of injected stylesheets is not valid: %w", err)
+		}
+	}
+	for _, inj := range rp.InjectJS {
+		if err := inj.Validate(); err != nil {
+			return fmt.Errorf("one of injected scripts is not valid: %w", err)
+		}
+	}
+	if rp.DisableJavaScript {
+		if len(rp.InjectJS) > 0 {
+			return errors.New("can't inject scripts with JavaScript disabled")
+		}
+		if rp.WaitFunction != nil {
+			return errors.New("can't wait for a function with JavaScript disabled")
+		}
+	}
+	if rp.Locale != "" {
+		if _, err := language.Parse(rp.Locale); err != nil {
+			return fmt.Errorf("invalid locale: %w", err)
+		}
+	}
+	if rp.Timezone != "" {
+		if rp.Timezone == "Local" {
+			return errors.New("invalid timezone: Local is not an IANA time zone name")
+		}
+		if _, err := time.LoadLocation(rp.Timezone); err != nil {
+			return fmt.Errorf("invalid timezone: %w", err)
+		}
+	}
+	if rp.WaitNetworkIdle < 0 {
+		return errors.New("negative value for network idle time")
+	}
+	if rp.WaitNetworkIdle > maxWaitTime {
+		return errors.New("too long network idle time. Maximum is 3 minutes")
+	}
+	totalWait := rp.WaitTime + rp.WaitNetworkIdle
+	if rp.WaitFunction != nil {
+		if err := rp.WaitFunction.Validate(); err != nil {
+			return fmt.Errorf("wait function is not valid: %w", err)
+		}
+		totalWait += rp.WaitFunction.Timeout
+	}
+	if totalWait > maxWaitTime {
+		return errors.New("too long total wait time. Maximum is 3 minutes")
+	}
 	return nil
 }
 
@@ -326,6 +641,105 @@ func (q *QueryBuilder) WaitVisible(selector string, by selector.ByType) *QueryBu
 	return q
 }
 
+// WaitFunction polls the JavaScript expression every pollInterval until it evaluates to a truthy value
+// or the timeout is reached.
+func (q *QueryBuilder) WaitFunction(expression string, pollInterval, timeout time.Duration) *QueryBuilder {
+	q.query.RenderParameters.WaitFunction = &WaitFunction{Expression: expression, PollInterval: pollInterval, Timeout: timeout}
+	return q
+}
+
+// WaitNetworkIdle waits until there is no network activity on the page for the provided duration.
+func (q *QueryBuilder) WaitNetworkIdle(d time.Duration) *QueryBuilder {
+	q.query.RenderParameters.WaitNetworkIdle = d
+	return q
+}
+
+// WaitHidden waits for the selector to get hidden or removed from the DOM.
+func (q *QueryBuilder) WaitHidden(selector string, by selector.ByType) *QueryBuilder {
+	q.query.RenderParameters.WaitHidden = append(q.query.RenderParameters.WaitHidden, BySelector{Selector: selector, By: by})
+	return q
+}
+
+// WaitNotPresent waits for the selector to get removed from the DOM.
+func (q *QueryBuilder) WaitNotPresent(selector string, by selector.ByType) *QueryBuilder {
+	q.query.RenderParameters.WaitNotPresent = append(q.query.RenderParameters.WaitNotPresent, BySelector{Selector: selector, By: by})
+	return q
+}
+
+// InjectCSS injects the inline stylesheet into the page after it's loaded.
+func (q *QueryBuilder) InjectCSS(css string) *QueryBuilder {
+	q.query.RenderParameters.InjectCSS = append(q.query.RenderParameters.InjectCSS, Injection{Content: css})
+	return q
+}
+
+// InjectCSSFile injects the stylesheet file at the path within the content bundle into the page after it's loaded.
+func (q *QueryBuilder) InjectCSSFile(path string) *QueryBuilder {
+	q.query.RenderParameters.InjectCSS = append(q.query.RenderParameters.InjectCSS, Injection{Path: path})
+	return q
+}
+
+// InjectJS executes the inline script on the page after it's loaded.
+func (q *QueryBuilder) InjectJS(js string) *QueryBuilder {
+	q.query.RenderParameters.InjectJS = append(q.query.RenderParameters.InjectJS, Injection{Content: js})
+	return q
+}
+
+// InjectJSFile executes the script file at the path within the content bundle on the page after it's loaded.
+func (q *QueryBuilder) InjectJSFile(path string) *QueryBuilder {
+	q.query.RenderParameters.InjectJS = append(q.query.RenderParameters.InjectJS, Injection{Path: path})
+	return q
+}
+
+// DisableJavaScript disables JavaScript execution on the rendered page.
+func (q *QueryBuilder) DisableJavaScript() *QueryBuilder {
+	q.query.RenderParameters.DisableJavaScript = true
+	return q
+}
+
+// FailOnResourceError makes the conversion fail when any of the page sub-resources failed to load.
+func (q *QueryBuilder) FailOnResourceError() *QueryBuilder {
+	q.query.RenderParameters.FailOnResourceError = true
+	return q
+}
+
+// FailOnHTTPError makes the conversion fail when the main "web" URL returns a non-2xx status.
+func (q *QueryBuilder) FailOnHTTPError() *QueryBuilder {
+	q.query.RenderParameters.FailOnHTTPError = true
+	return q
+}
+
+// Locale sets the BCP 47 language tag emulated by the browser.
+func (q *QueryBuilder) Locale(locale string) *QueryBuilder {
+	q.query.RenderParameters.Locale = locale
+	return q
+}
+
+// Timezone sets the IANA time zone name emulated by the browser.
+func (q *QueryBuilder) Timezone(timezone string) *QueryBuilder {
+	q.query.RenderParameters.Timezone = timezone
+	return q
+}
+
+// DefaultFontFamily sets the font family used for the text with no font family defined.
+func (q *QueryBuilder) DefaultFontFamily(family string) *QueryBuilder {
+	q.query.RenderParameters.DefaultFontFamily = family
+	return q
+}
+
+// TaggedPDF makes the server generate the tagged PDF with the structure tree built from the HTML semantics.
+func (q *QueryBuilder) TaggedPDF() *QueryBuilder {
+	q.query.RenderParameters.TaggedPDF = true
+	return q
+}
+
+// DocumentOutline makes the server embed the PDF outline generated from the page headings.
+// The outline is built from the tagged PDF structure, so that the TaggedPDF is set as well.
+func (q *QueryBuilder) DocumentOutline() *QueryBuilder {
+	q.query.RenderParameters.TaggedPDF = true
+	q.query.RenderParameters.DocumentOutline = true
+	return q
+}
+
 // WithPrefix sets the client prefix.
 func WithPrefix(prefix string) Option { return func(_ag *Options) { _ag.Prefix = prefix } }
 
@@ -393,6 +807,7 @@ func (cli *Client) getGenerateRequest(ctx context.Context, q *Query) (*http.Requ
 		return nil, err
 	}
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", acceptHeader)
 	req.Header.Set("Accept-Encoding", "deflate, gzip;q=1.0, *;q=0.5")
 
 	return req.WithContext(ctx), nil
@@ -435,6 +850,9 @@ var (
 	ErrBadGateway     = errors.New("bad gateway")
 	ErrUnauthorized   = errors.New("unauthorized")
 	ErrTimedOut       = errors.New("request timed out")
+
+	ErrResourceFailed     = errors.New("page resource failed to load")
+	ErrMainResourceStatus = errors.New("page returned non-2xx status")
 )
 
 // Query is a structure that contains query parameters and the content used for the HTMLConverter conversion process.
@@ -477,7 +895,7 @@ func (cli *Client) ConvertHTML(ctx context.Context, q *Query) (*PDFResponse, err
 
 	httpClient := *cli.Client
 	if q.TimeoutDuration != 0 {
-		httpClient.Timeout = q.TimeoutDuration
+		httpClient.Timeout = q.TimeoutDuration + ResponseTimeout
 	}
 
 	resp, err := httpClient.Do(req)
@@ -519,20 +937,27 @@ func (cli *Client) ConvertHTML(ctx context.Context, q *Query) (*PDFResponse, err
 		return nil, fmt.Errorf("unsupported Content-Encoding: %s header", resp.Header.Get("Content-Encoding"))
 	}
 
-	data, readErr := io.ReadAll(reader)
+	data, diagnostics, readErr := readResponseBody(reader, resp.Header)
 	if readErr != nil && httpErr == nil {
 		return nil, fmt.Errorf("UniHTML server error %s", readErr)
 	}
 
 	common.Log.Trace("[%d] %s %s%s", resp.StatusCode, req.Method, req.URL.Host, req.URL.Path)
 	if httpErr != nil {
-		return nil, fmt.Errorf("%s %w", string(data), httpErr)
+		err = fmt.Errorf("%s %w", string(data), httpErr)
+		if diagnostics != nil {
+			return nil, &DiagnosticsError{Err: err, Diagnostics: diagnostics}
+		}
+		return nil, err
+	}
+	if err = q.RenderParameters.checkDiagnostics(diagnostics); err != nil {
+		return nil, err
 	}
 
 	jobID := resp.Header.Get("X-Job-ID")
 	common.Log.Trace("Response ID %s", jobID)
 
-	return &PDFResponse{ID: jobID, Data: data}, nil
+	return &PDFResponse{ID: jobID, Data: data, Diagnostics: diagnostics}, nil
 }
 
 // Validate checks if provided Query is valid.
@@ -559,9 +984,55 @@ func (q *Query) Validate() error {
 	if err := q.RenderParameters.Validate(); err != nil {
 		return err
 	}
+	if q.Method != "web" && q.RenderParameters.FailOnHTTPError {
+		return errors.New("failing on HTTP error is only available for the web content")
+	}
+	if q.Method != "dir" && q.RenderParameters.hasInjectedFiles() {
+		return errors.New("injected files are only available for the directory content")
+	}
 	return nil
 }
 
+func (rp *RenderParameters) hasInjectedFiles() bool {
+	for _, inj := range rp.InjectCSS {
+		if inj.Path != "" {
+			return true
+		}
+	}
+	for _, inj := range rp.InjectJS {
+		if inj.Path != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Hash gets the hex encoded SHA-256 hash of the query content and its page and render parameters.
+// Queries with equal hashes are expected to produce the same output.
+func (q *Query) Hash() (string, error) {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%s\x00", q.Method, q.ContentType, q.URL)
+	h.Write(q.Content)
+
+	p := q.PageParameters
+	for _, l := range []sizes.Length{p.PaperWidth, p.PaperHeight, p.MarginTop, p.MarginBottom, p.MarginLeft, p.MarginRight} {
+		if l == nil {
+			h.Write([]byte{0})
+			continue
+		}
+		fmt.Fprintf(h, "\x00%g", float64(l.Millimeters()))
+	}
+	if p.PageSize != nil {
+		fmt.Fprintf(h, "\x00%s", p.PageSize.String())
+	}
+	fmt.Fprintf(h, "\x00%t\x00", bool(p.Orientation))
+
+	if err := json.NewEncoder(h).Encode(&q.RenderParameters); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
 // Landscape sets up the landscape portrait orientation.
 func (q *QueryBuilder) Landscape() *QueryBuilder {
 	q.query.PageParameters.Orientation = sizes.Landscape
@@ -0,0 +1,397 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/unitechio/gohtml/selector"
+	"github.com/unitechio/gohtml/sizes"
+)
+
+func TestRenderParametersValidate(t *testing.T) {
+	validSel := []BySelector{{Selector: "#done", By: selector.ByQuery}}
+	emptySel := []BySelector{{By: selector.ByQuery}}
+	waitFunc := func(timeout time.Duration) *WaitFunction {
+		return &WaitFunction{Expression: "window.ready", PollInterval: time.Second, Timeout: timeout}
+	}
+
+	testCases := []struct {
+		name    string
+		params  RenderParameters
+		wantErr bool
+	}{
+		{name: "empty"},
+		{name: "max wait time", params: RenderParameters{WaitTime: maxWaitTime}},
+		{name: "too long wait time", params: RenderParameters{WaitTime: maxWaitTime + time.Second}, wantErr: true},
+		{name: "wait function", params: RenderParameters{WaitFunction: waitFunc(time.Minute)}},
+		{name: "empty wait function", params: RenderParameters{WaitFunction: &WaitFunction{PollInterval: time.Second, Timeout: time.Second}}, wantErr: true},
+		{name: "wait function poll exceeds timeout", params: RenderParameters{WaitFunction: &WaitFunction{Expression: "true", PollInterval: time.Minute, Timeout: time.Second}}, wantErr: true},
+		{name: "too long wait function", params: RenderParameters{WaitFunction: waitFunc(maxWaitTime + time.Second)}, wantErr: true},
+		{name: "wait time with wait function", params: RenderParameters{WaitTime: time.Minute, WaitFunction: waitFunc(2 * time.Minute)}},
+		{name: "too long wait time with wait function", params: RenderParameters{WaitTime: 2 * time.Minute, WaitFunction: waitFunc(2 * time.Minute)}, wantErr: true},
+		{name: "network idle", params: RenderParameters{WaitNetworkIdle: time.Second}},
+		{name: "negative network idle", params: RenderParameters{WaitNetworkIdle: -time.Second}, wantErr: true},
+		{name: "too long network idle", params: RenderParameters{WaitNetworkIdle: maxWaitTime + time.Second}, wantErr: true},
+		{name: "too long wait time with network idle", params: RenderParameters{WaitTime: 2 * time.Minute, WaitNetworkIdle: 2 * time.Minute}, wantErr: true},
+		{name: "too long combined wait", params: RenderParameters{WaitTime: time.Minute, WaitNetworkIdle: time.Minute, WaitFunction: waitFunc(time.Minute + time.Second)}, wantErr: true},
+		{name: "wait hidden", params: RenderParameters{WaitHidden: validSel}},
+		{name: "invalid wait hidden", params: RenderParameters{WaitHidden: emptySel}, wantErr: true},
+		{name: "wait hidden undefined by", params: RenderParameters{WaitHidden: []BySelector{{Selector: "#x"}}}, wantErr: true},
+		{name: "wait not present", params: RenderParameters{WaitNotPresent: validSel}},
+		{name: "invalid wait not present", params: RenderParameters{WaitNotPresent: emptySel}, wantErr: true},
+		{name: "inject css", params: RenderParameters{InjectCSS: []Injection{{Content: "body{}"}}}},
+		{name: "empty injection", params: RenderParameters{InjectCSS: []Injection{{}}}, wantErr: true},
+		{name: "injection with content and path", params: RenderParameters{InjectJS: []Injection{{Content: "x()", Path: "x.js"}}}, wantErr: true},
+		{name: "inject css with javascript disabled", params: RenderParameters{InjectCSS: []Injection{{Content: "body{}"}}, DisableJavaScript: true}},
+		{name: "inject js with javascript disabled", params: RenderParameters{InjectJS: []Injection{{Content: "x()"}}, DisableJavaScript: true}, wantErr: true},
+		{name: "wait function with javascript disabled", params: RenderParameters{WaitFunction: waitFunc(time.Second), DisableJavaScript: true}, wantErr: true},
+		{name: "locale", params: RenderParameters{Locale: "vi-VN"}},
+		{name: "invalid locale", params: RenderParameters{Locale: "not a locale"}, wantErr: true},
+		{name: "timezone", params: RenderParameters{Timezone: "Europe/Berlin"}},
+		{name: "utc timezone", params: RenderParameters{Timezone: "UTC"}},
+		{name: "local timezone", params: RenderParameters{Timezone: "Local"}, wantErr: true},
+		{name: "unknown timezone", params: RenderParameters{Timezone: "Mars/Olympus"}, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.params.Validate()
+			if (err != nil) != tc.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestQueryValidate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		query   Query
+		wantErr error
+		anyErr  bool
+	}{
+		{name: "web", query: Query{Method: "web", URL: "https://example.com"}},
+		{name: "web without url", query: Query{Method: "web"}, wantErr: ErrMissingData},
+		{name: "html without content", query: Query{Method: "html", ContentType: "text/html"}, wantErr: ErrMissingData},
+		{name: "html without content type", query: Query{Method: "html", Content: []byte("<p>")}, wantErr: ErrContentType},
+		{name: "undefined method", query: Query{Method: "ftp"}, anyErr: true},
+		{
+			name:   "http error fail for html",
+			query:  Query{Method: "html", Content: []byte("<p>"), ContentType: "text/html", RenderParameters: RenderParameters{FailOnHTTPError: true}},
+			anyErr: true,
+		},
+		{
+			name:  "http error fail for web",
+			query: Query{Method: "web", URL: "https://example.com", RenderParameters: RenderParameters{FailOnHTTPError: true}},
+		},
+		{
+			name:   "injected file for html",
+			query:  Query{Method: "html", Content: []byte("<p>"), ContentType: "text/html", RenderParameters: RenderParameters{InjectCSS: []Injection{{Path: "a.css"}}}},
+			anyErr: true,
+		},
+		{
+			name:  "injected file for dir",
+			query: Query{Method: "dir", Content: []byte("zip"), ContentType: "application/zip", RenderParameters: RenderParameters{InjectJS: []Injection{{Path: "a.js"}}}},
+		},
+		{
+			name:   "negative margin",
+			query:  Query{Method: "web", URL: "https://example.com", PageParameters: PageParameters{MarginTop: sizes.Millimeter(-1)}},
+			anyErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.query.Validate()
+			switch {
+			case tc.wantErr != nil:
+				if !errors.Is(err, tc.wantErr) {
+					t.Errorf("Validate() error = %v, want %v", err, tc.wantErr)
+				}
+			case tc.anyErr:
+				if err == nil {
+					t.Error("Validate() expected an error")
+				}
+			case err != nil:
+				t.Errorf("Validate() unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestQueryHash(t *testing.T) {
+	base := func() Query {
+		return Query{
+			Method:         "html",
+			ContentType:    "text/html",
+			Content:        []byte("<p>hello</p>"),
+			PageParameters: PageParameters{MarginTop: sizes.Millimeter(25.4)},
+		}
+	}
+	baseHash, err := func() (string, error) { q := base(); return q.Hash() }()
+	if err != nil {
+		t.Fatalf("Hash() failed: %v", err)
+	}
+
+	testCases := []struct {
+		name   string
+		modify func(q *Query)
+		equal  bool
+	}{
+		{name: "same", modify: func(q *Query) {}, equal: true},
+		{name: "equal margin in other unit", modify: func(q *Query) { q.PageParameters.MarginTop = sizes.Inch(1) }, equal: true},
+		{name: "content", modify: func(q *Query) { q.Content = []byte("<p>world</p>") }},
+		{name: "margin", modify: func(q *Query) { q.PageParameters.MarginTop = sizes.Millimeter(25) }},
+		{name: "margin moved", modify: func(q *Query) {
+			q.PageParameters.MarginTop = nil
+			q.PageParameters.MarginBottom = sizes.Millimeter(25.4)
+		}},
+		{name: "orientation", modify: func(q *Query) { q.PageParameters.Orientation = sizes.Landscape }},
+		{name: "wait time", modify: func(q *Query) { q.RenderParameters.WaitTime = time.Second }},
+		{name: "network idle", modify: func(q *Query) { q.RenderParameters.WaitNetworkIdle = time.Second }},
+		{name: "wait hidden", modify: func(q *Query) {
+			q.RenderParameters.WaitHidden = []BySelector{{Selector: "#x", By: selector.ByQuery}}
+		}},
+		{name: "injected css", modify: func(q *Query) { q.RenderParameters.InjectCSS = []Injection{{Content: "p{}"}} }},
+		{name: "locale", modify: func(q *Query) { q.RenderParameters.Locale = "de-DE" }},
+		{name: "timezone", modify: func(q *Query) { q.RenderParameters.Timezone = "Europe/Berlin" }},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q := base()
+			tc.modify(&q)
+			hash, err := q.Hash()
+			if err != nil {
+				t.Fatalf("Hash() failed: %v", err)
+			}
+			if (hash == baseHash) != tc.equal {
+				t.Errorf("Hash() equal = %v, want %v", hash == baseHash, tc.equal)
+			}
+		})
+	}
+}
+
+func TestParseDiagnostics(t *testing.T) {
+	encode := func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) }
+
+	testCases := []struct {
+		name       string
+		header     string
+		wantErr    bool
+		wantNil    bool
+		wantErrors bool
+		wantStatus int
+	}{
+		{name: "no header", wantNil: true},
+		{name: "invalid base64", header: "%%%", wantErr: true, wantNil: true},
+		{name: "invalid json", header: encode("{"), wantErr: true, wantNil: true},
+		{name: "main status only", header: encode(`{"mainStatus":404}`), wantStatus: 404},
+		{name: "console warning", header: encode(`{"console":[{"level":"warning","text":"deprecated"}]}`)},
+		{name: "console error", header: encode(`{"console":[{"level":"error","text":"boom"}]}`), wantErrors: true},
+		{name: "failed resource", header: encode(`{"failedResources":[{"url":"a.png","status":404}]}`), wantErrors: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			header := http.Header{}
+			if tc.header != "" {
+				header.Set(diagnosticsHeader, tc.header)
+			}
+			d, err := parseDiagnostics(header)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("parseDiagnostics() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if (d == nil) != tc.wantNil {
+				t.Fatalf("parseDiagnostics() = %v, wantNil %v", d, tc.wantNil)
+			}
+			if d == nil {
+				return
+			}
+			if d.HasErrors() != tc.wantErrors {
+				t.Errorf("HasErrors() = %v, want %v", d.HasErrors(), tc.wantErrors)
+			}
+			if d.MainStatus != tc.wantStatus {
+				t.Errorf("MainStatus = %d, want %d", d.MainStatus, tc.wantStatus)
+			}
+		})
+	}
+}
+
+func TestCheckDiagnostics(t *testing.T) {
+	failed := &Diagnostics{FailedResources: []FailedResource{{URL: "a.png", Status: 404}}}
+	notFound := &Diagnostics{MainStatus: 404}
+
+	testCases := []struct {
+		name    string
+		params  RenderParameters
+		d       *Diagnostics
+		wantErr error
+	}{
+		{name: "nil diagnostics", params: RenderParameters{FailOnResourceError: true, FailOnHTTPError: true}},
+		{name: "failed resource ignored", d: failed},
+		{name: "failed resource", params: RenderParameters{FailOnResourceError: true}, d: failed, wantErr: ErrResourceFailed},
+		{name: "main status ignored", d: notFound},
+		{name: "main status", params: RenderParameters{FailOnHTTPError: true}, d: notFound, wantErr: ErrMainResourceStatus},
+		{name: "main status ok", params: RenderParameters{FailOnHTTPError: true}, d: &Diagnostics{MainStatus: 200}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.params.checkDiagnostics(tc.d)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("checkDiagnostics() error = %v, want %v", err, tc.wantErr)
+			}
+			var diagErr *DiagnosticsError
+			if err != nil && (!errors.As(err, &diagErr) || diagErr.Diagnostics != tc.d) {
+				t.Errorf("checkDiagnostics() error doesn't carry the diagnostics: %v", err)
+			}
+		})
+	}
+}
+
+// newMultipartBody creates the multipart/mixed response body with the PDF data part and the diagnostics part.
+func newMultipartBody(t *testing.T, data []byte, diagnostics string) (string, []byte) {
+	t.Helper()
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	pdfPart, err := mw.CreatePart(textproto.MIMEHeader{"Content-Type": {"application/pdf"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pdfPart.Write(data)
+	if diagnostics != "" {
+		diagPart, err := mw.CreatePart(textproto.MIMEHeader{"Content-Type": {"application/json; charset=utf-8"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		diagPart.Write([]byte(diagnostics))
+	}
+	if err = mw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return mw.FormDataContentType(), buf.Bytes()
+}
+
+func TestReadResponseBody(t *testing.T) {
+	manyFailed := &strings.Builder{}
+	manyFailed.WriteString(`{"failedResources":[`)
+	const numFailed = 5000
+	for i := 0; i < numFailed; i++ {
+		if i > 0 {
+			manyFailed.WriteByte(',')
+		}
+		fmt.Fprintf(manyFailed, `{"url":"https://example.com/assets/image-%d.png","status":404}`, i)
+	}
+	manyFailed.WriteString(`]}`)
+
+	testCases := []struct {
+		name          string
+		diagnostics   string
+		multipart     bool
+		wantFailed    int
+		wantNoDiagnos bool
+	}{
+		{name: "plain body", wantNoDiagnos: true},
+		{name: "plain body with header", diagnostics: `{"failedResources":[{"url":"a.png"}]}`, wantFailed: 1},
+		{name: "multipart without diagnostics", multipart: true, wantNoDiagnos: true},
+		{name: "multipart", multipart: true, diagnostics: `{"failedResources":[{"url":"a.png"}]}`, wantFailed: 1},
+		{name: "multipart many failed resources", multipart: true, diagnostics: manyFailed.String(), wantFailed: numFailed},
+		{name: "multipart invalid diagnostics", multipart: true, diagnostics: "{", wantNoDiagnos: true},
+	}
+
+	pdf := []byte("%PDF-1.7 test")
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			header := http.Header{}
+			body := pdf
+			if tc.multipart {
+				var contentType string
+				contentType, body = newMultipartBody(t, pdf, tc.diagnostics)
+				header.Set("Content-Type", strings.Replace(contentType, "multipart/form-data", "multipart/mixed", 1))
+			} else {
+				header.Set("Content-Type", "application/pdf")
+				if tc.diagnostics != "" {
+					header.Set(diagnosticsHeader, base64.StdEncoding.EncodeToString([]byte(tc.diagnostics)))
+				}
+			}
+
+			data, d, err := readResponseBody(bytes.NewReader(body), header)
+			if err != nil {
+				t.Fatalf("readResponseBody() failed: %v", err)
+			}
+			if !bytes.Equal(data, pdf) {
+				t.Errorf("data = %q, want %q", data, pdf)
+			}
+			if tc.wantNoDiagnos {
+				if d != nil {
+					t.Errorf("diagnostics = %v, want nil", d)
+				}
+				return
+			}
+			if d == nil {
+				t.Fatal("diagnostics not read")
+			}
+			if len(d.FailedResources) != tc.wantFailed {
+				t.Errorf("failed resources = %d, want %d", len(d.FailedResources), tc.wantFailed)
+			}
+		})
+	}
+}
+
+func TestConvertHTMLMultipartDiagnostics(t *testing.T) {
+	pdf := []byte("%PDF-1.7 test")
+	diagnostics := `{"console":[{"level":"error","text":"boom"}],"failedResources":[{"url":"a.png","status":404}]}`
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Accept"), "multipart/mixed") {
+			t.Errorf("Accept header = %q, expected multipart/mixed", r.Header.Get("Accept"))
+		}
+		contentType, body := newMultipartBody(t, pdf, diagnostics)
+		w.Header().Set("Content-Type", strings.Replace(contentType, "multipart/form-data", "multipart/mixed", 1))
+		w.Header().Set("X-Job-ID", "job-1")
+		w.WriteHeader(http.StatusCreated)
+		w.Write(body)
+	}))
+	defer srv.Close()
+
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	port, err := strconv.Atoi(u.Port())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cli := &Client{Options: Options{Hostname: u.Hostname(), Port: port}, Client: srv.Client()}
+	q := &Query{Method: "html", ContentType: "text/html", Content: []byte("<p>hello</p>")}
+
+	resp, err := cli.ConvertHTML(context.Background(), q)
+	if err != nil {
+		t.Fatalf("ConvertHTML() failed: %v", err)
+	}
+	if !bytes.Equal(resp.Data, pdf) || resp.ID != "job-1" {
+		t.Errorf("ConvertHTML() = %q %q, want %q job-1", resp.ID, resp.Data, pdf)
+	}
+	if !resp.Diagnostics.HasErrors() || len(resp.Diagnostics.FailedResources) != 1 {
+		t.Errorf("ConvertHTML() diagnostics = %+v", resp.Diagnostics)
+	}
+
+	q.RenderParameters.FailOnResourceError = true
+	_, err = cli.ConvertHTML(context.Background(), q)
+	if !errors.Is(err, ErrResourceFailed) {
+		t.Errorf("ConvertHTML() error = %v, want %v", err, ErrResourceFailed)
+	}
+}
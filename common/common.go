@@ -8,6 +8,13 @@ const (
 	formatLayout  = "2 January 2006 at 15:04"
 )
 
+// Layouts used for formatting the timestamps in the documents.
+const (
+	DateLayout     = "2 January 2006"
+	DateTimeLayout = formatLayout
+	ISODateLayout  = "2006-01-02"
+)
+
 var (
 	ReleasedAt = time.Date(2025, time.March, 9, 12, 20, 0, 0, time.UTC)
 	Version    = versionString
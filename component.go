@@ -0,0 +1,307 @@
+package gohtml
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/unitechio/gohtml/sizes"
+	"github.com/unitechio/gopdf/common"
+	"github.com/unitechio/gopdf/core"
+	"github.com/unitechio/gopdf/creator"
+	"github.com/unitechio/gopdf/model"
+)
+
+// defaultComponentHeight is the page height used for rendering the component document, when
+// the page height is not set.
+var defaultComponentHeight sizes.Length = sizes.Millimeter(297)
+
+// SetWidth sets the width of the Document drawn within the creator containers other than the creator.Chapter,
+// i.e. the creator.Division. By default the document takes the whole width available in the container.
+func (d *Document) SetWidth(w sizes.Length) { d.width = w }
+
+// Width implements creator.VectorDrawable interface. It gets the width set by the SetWidth,
+// or zero if the document takes the whole width available in the container.
+func (d *Document) Width() float64 {
+	if d.width == nil {
+		return 0
+	}
+	return float64(d.width.Points())
+}
+
+// Height implements creator.VectorDrawable interface. It gets the content height rendered at the SetWidth width,
+// or zero if the width is not set or the render fails.
+func (d *Document) Height() float64 {
+	if d.width == nil {
+		return 0
+	}
+	m, err := d.Measure(d.getContext(), d.width)
+	if err != nil {
+		common.Log.Debug("Measuring document height failed: %v", err)
+		return 0
+	}
+	return float64(m.Height)
+}
+
+// Measurement is the size of the document content rendered at a given width.
+type Measurement struct {
+	// Height is the total height of the content on all pages.
+	Height sizes.Point
+
+	// Pages is the number of rendered pages.
+	Pages int
+
+	// PageHeights are the heights of the content on each of the pages. Each page, except the last, takes
+	// the whole page height, the last page height excludes the empty space at its bottom.
+	PageHeights []sizes.Point
+}
+
+// Measure gets the size of the document content rendered at the width with no margins on the SetPageHeight
+// or A4 high pages. The render is reused by the document drawn within a container at the same width.
+func (d *Document) Measure(ctx context.Context, width sizes.Length) (Measurement, error) {
+	if err := d.validate(); err != nil {
+		return Measurement{}, err
+	}
+	if width == nil || width.Points() <= 0 {
+		return Measurement{}, errors.New("provided invalid measure width")
+	}
+	_, heights, err := d.renderComponent(ctx, width, d.componentPageHeight())
+	if err != nil {
+		return Measurement{}, err
+	}
+	m := Measurement{Pages: len(heights), PageHeights: make([]sizes.Point, len(heights))}
+	for i, h := range heights {
+		m.PageHeights[i] = sizes.Point(h)
+		m.Height += sizes.Point(h)
+	}
+	return m, nil
+}
+
+// componentPageHeight gets the page height the component document is rendered at. It doesn't depend
+// on the container, so that the document is measured and drawn by the same render.
+func (d *Document) componentPageHeight() sizes.Length {
+	if d.pageHeight != nil {
+		return d.pageHeight
+	}
+	return defaultComponentHeight
+}
+
+// renderComponent renders the document pages at provided width with no margins and measures
+// the height of the content of each page.
+func (d *Document) renderComponent(cctx context.Context, w, h sizes.Length) (*model.PdfReader, []float64, error) {
+	zero := sizes.Point(0)
+	reader, err := d.read(cctx, w, h, margins{Left: zero, Right: zero, Top: zero, Bottom: zero})
+	if err != nil {
+		return nil, nil, err
+	}
+	pages := reader.PageList
+	heights := make([]float64, len(pages))
+	for i, p := range pages {
+		mbox, err := p.GetMediaBox()
+		if err != nil {
+			return nil, nil, err
+		}
+		heights[i] = mbox.Height()
+		if i != len(pages)-1 && !d.trimOptions().AllPages {
+			continue
+		}
+		trimHeight, err := measureTrimHeight(p, d.trimOptions())
+		if err != nil {
+			return nil, nil, err
+		}
+		heights[i] -= trimHeight
+	}
+	return reader, heights, nil
+}
+
+// generateComponentBlocks draws the rendered pages clipped to their content one after another, splitting them
+// at the creator page bottom. The document positioned by the SetPos keeps the context unchanged.
+func (d *Document) generateComponentBlocks(cctx context.Context, ctx creator.DrawContext) ([]*creator.Block, creator.DrawContext, error) {
+	origCtx := ctx
+	if d.hasPos {
+		ctx.X, ctx.Y = d.posX, d.posY
+		ctx.Height = ctx.PageHeight - ctx.Margins.Bottom - d.posY
+	}
+	w := d.width
+	if w == nil {
+		w = sizes.Point(ctx.Width)
+	}
+
+	reader, heights, err := d.renderComponent(cctx, w, d.componentPageHeight())
+	if err != nil {
+		return nil, origCtx, err
+	}
+	links, err := newPageLinks(reader, true)
+	if err != nil {
+		return nil, origCtx, err
+	}
+
+	pb := pageBlocks{start: ctx.Page}
+	var placements []pagePlacement
+	for i, p := range reader.PageList {
+		mbox, err := p.GetMediaBox()
+		if err != nil {
+			return nil, origCtx, err
+		}
+		if heights[i] <= 0 {
+			// The links to the empty page target the position the next page is drawn at.
+			box := model.PdfRectangle{Llx: mbox.Llx, Lly: mbox.Ury, Urx: mbox.Llx + float64(w.Points()), Ury: mbox.Ury}
+			placements = append(placements, pagePlacement{source: i, page: int64(ctx.Page - 1), x: ctx.X, y: ctx.Y, box: box})
+			continue
+		}
+		for top, rest := mbox.Ury, heights[i]; rest > 0; {
+			// Move to the next page if the content doesn't fit and the current page is not empty.
+			if rest > ctx.Height && ctx.Y > ctx.Margins.Top {
+				ctx.Page++
+				ctx.Y = ctx.Margins.Top
+				ctx.Height = ctx.PageHeight - ctx.Margins.Top - ctx.Margins.Bottom
+			}
+			h := min(rest, ctx.Height)
+			if h <= 0 {
+				return nil, origCtx, errors.New("no space left for the document on the creator page")
+			}
+			box := model.PdfRectangle{Llx: mbox.Llx, Lly: top - h, Urx: mbox.Llx + float64(w.Points()), Ury: top}
+			clipped, err := cropPage(p, box)
+			if err != nil {
+				return nil, origCtx, err
+			}
+			if clipped, err = d.markContent(clipped, ctx.Page); err != nil {
+				return nil, origCtx, err
+			}
+			block, err := creator.NewBlockFromPage(clipped)
+			if err != nil {
+				return nil, origCtx, err
+			}
+			links.add(block, i, box)
+			if err = pb.draw(ctx, block); err != nil {
+				return nil, origCtx, err
+			}
+			placements = append(placements, pagePlacement{source: i, page: int64(ctx.Page - 1), x: ctx.X, y: ctx.Y, box: box})
+			top, rest = top-h, rest-h
+			ctx.Y += h
+			ctx.Height -= h
+		}
+	}
+	links.place(placements, ctx.PageHeight)
+	if d.outline {
+		if err = d.addOutline(reader, placements); err != nil {
+			return nil, origCtx, err
+		}
+	}
+	if err = d.drawWatermarks(cctx, pb); err != nil {
+		return nil, origCtx, err
+	}
+	if d.hasPos {
+		return pb.blocks, origCtx, nil
+	}
+	return pb.blocks, ctx, nil
+}
+
+// pageBlocks are the page sized blocks of the consecutive pages, starting at the 'start' page,
+// as expected by the creator from the Drawable.
+type pageBlocks struct {
+	start  int
+	blocks []*creator.Block
+}
+
+// draw draws the block at the context position on the context page.
+func (pb *pageBlocks) draw(ctx creator.DrawContext, b *creator.Block) error {
+	for len(pb.blocks) <= ctx.Page-pb.start {
+		pb.blocks = append(pb.blocks, creator.NewBlock(ctx.PageWidth, ctx.PageHeight))
+	}
+	b.SetPos(ctx.X, ctx.Y)
+	return pb.blocks[ctx.Page-pb.start].Draw(b)
+}
+
+// cropPage gets a copy of the page with the media box set to the box and its content clipped to it.
+func cropPage(p *model.PdfPage, box model.PdfRectangle) (*model.PdfPage, error) {
+	cs, err := p.GetAllContentStreams()
+	if err != nil {
+		return nil, err
+	}
+	cp := p.Duplicate()
+	clip := fmt.Sprintf("q\n%.4f %.4f %.4f %.4f re W n\n", box.Llx, box.Lly, box.Width(), box.Height())
+	if err = cp.SetContentStreams([]string{clip + cs + "\nQ\n"}, core.NewFlateEncoder()); err != nil {
+		return nil, err
+	}
+	cp.MediaBox = &box
+	cp.CropBox = nil
+	return cp, nil
+}
+
+// ===================== FRAGMENT =====================
+
+// ErrFragmentTooTall is returned when the fragment content doesn't fit a single page.
+var ErrFragmentTooTall = errors.New("html fragment doesn't fit a single page, set a larger page height")
+
+// errFragmentEmpty is returned when the rendered fragment has no visible content to crop.
+var errFragmentEmpty = errors.New("html fragment has no visible content")
+
+// defaultFragmentHeight is the page height requested for rendering the fragment, tall enough
+// for the charts, signature blocks and address panels.
+var defaultFragmentHeight sizes.Length = sizes.Millimeter(1000)
+
+// Fragment renders the document at provided width as a single block cropped to the bounding box of its content
+// on all four sides. The returned block can be placed with its SetPos or drawn in the relative flow.
+// The fragment is rendered on a page tall enough for most of the snippets, a taller page can be
+// requested with the SetPageHeight.
+func (d *Document) Fragment(ctx context.Context, width sizes.Length) (*creator.Block, error) {
+	reader, page, err := d.fragmentPage(ctx, width)
+	if err != nil {
+		return nil, err
+	}
+	block, err := creator.NewBlockFromPage(page)
+	if err != nil {
+		return nil, err
+	}
+
+	// The fragment position is not known, so that only the URI links are kept.
+	links, err := newPageLinks(reader, false)
+	if err != nil {
+		return nil, err
+	}
+	links.add(block, 0, *page.MediaBox)
+	return block, nil
+}
+
+// fragmentPage renders the fragment page cropped to its content box, along with the reader of the rendered document.
+func (d *Document) fragmentPage(ctx context.Context, width sizes.Length) (*model.PdfReader, *model.PdfPage, error) {
+	if err := d.validate(); err != nil {
+		return nil, nil, err
+	}
+	if width == nil || width.Points() <= 0 {
+		return nil, nil, errors.New("provided invalid fragment width")
+	}
+	h := d.pageHeight
+	if h == nil {
+		h = defaultFragmentHeight
+	}
+
+	zero := sizes.Point(0)
+	reader, err := d.read(ctx, width, h, margins{Left: zero, Right: zero, Top: zero, Bottom: zero})
+	if err != nil {
+		return nil, nil, err
+	}
+	pages := reader.PageList
+	if len(pages) == 0 {
+		return nil, nil, ErrContentNotDefined
+	}
+	if len(pages) > 1 {
+		return nil, nil, ErrFragmentTooTall
+	}
+
+	opts := d.trimOptions()
+	opts.Sides = TrimAllSides
+	box, err := measureContentBox(pages[0], opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	if box.Height() <= 0 || box.Width() <= 0 {
+		return nil, nil, errFragmentEmpty
+	}
+	cropped, err := cropPage(pages[0], box)
+	if err != nil {
+		return nil, nil, err
+	}
+	return reader, cropped, nil
+}
@@ -0,0 +1,155 @@
+package gohtml
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/unitechio/gohtml/sizes"
+	"github.com/unitechio/gopdf/creator"
+)
+
+func TestMeasure(t *testing.T) {
+	testCases := []struct {
+		name     string
+		pages    []testPage
+		expected []float64
+	}{
+		{name: "single page", pages: []testPage{{300, 842, 200}}, expected: []float64{200}},
+		{name: "last page trimmed", pages: []testPage{{300, 842, 100}, {300, 842, 300}}, expected: []float64{842, 300}},
+		{name: "empty last page", pages: []testPage{{300, 842, 842}, {300, 842, 0}}, expected: []float64{842, 0}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := newTestDocument(t, &fakeConverter{data: newTestPages(t, tc.pages...)})
+			m, err := d.Measure(context.Background(), sizes.Point(300))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if m.Pages != len(tc.expected) {
+				t.Fatalf("expected %d pages, got %d", len(tc.expected), m.Pages)
+			}
+			var total float64
+			for i, h := range tc.expected {
+				if math.Abs(float64(m.PageHeights[i])-h) > 1 {
+					t.Errorf("expected page %d height %g, got %g", i, h, m.PageHeights[i])
+				}
+				total += h
+			}
+			if math.Abs(float64(m.Height)-total) > 1 {
+				t.Errorf("expected height %g, got %g", total, m.Height)
+			}
+		})
+	}
+
+	d := newTestDocument(t, &fakeConverter{data: newTestPages(t, testPage{300, 842, 200})})
+	if _, err := d.Measure(context.Background(), sizes.Point(0)); err == nil {
+		t.Error("expected the invalid width error")
+	}
+}
+
+// componentContext is the draw context of the 600x800 creator page with the 50pt margins.
+func componentContext(page int, y float64) creator.DrawContext {
+	return creator.DrawContext{
+		Page:       page,
+		X:          50,
+		Y:          y,
+		Width:      500,
+		Height:     750 - y,
+		Margins:    creator.Margins{Left: 50, Right: 50, Top: 50, Bottom: 50},
+		PageWidth:  600,
+		PageHeight: 800,
+	}
+}
+
+func TestGenerateComponentBlocks(t *testing.T) {
+	testCases := []struct {
+		name  string
+		pages []testPage
+		y     float64
+		pos   []float64
+		// blocks is the number of the page blocks, page and y are the expected context position after drawing.
+		blocks int
+		page   int
+		y2     float64
+	}{
+		{name: "fits the page", pages: []testPage{{500, 842, 200}}, y: 100, blocks: 1, page: 1, y2: 300},
+		{name: "moved to the next page", pages: []testPage{{500, 842, 600}}, y: 400, blocks: 2, page: 2, y2: 650},
+		{name: "split across the pages", pages: []testPage{{500, 1000, 990}}, y: 50, blocks: 2, page: 2, y2: 340},
+		{
+			name:   "pages one after another",
+			pages:  []testPage{{500, 400, 400}, {500, 400, 200}},
+			y:      50,
+			blocks: 1,
+			page:   1,
+			y2:     650,
+		},
+		{name: "empty last page", pages: []testPage{{500, 400, 400}, {500, 400, 0}}, y: 50, blocks: 1, page: 1, y2: 450},
+		{name: "positioned", pages: []testPage{{500, 842, 600}}, y: 400, pos: []float64{100, 300}, blocks: 2, page: 1, y2: 400},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := newTestDocument(t, &fakeConverter{data: newTestPages(t, tc.pages...)})
+			if _, err := d.ContainerComponent(creator.New().NewDivision()); err != nil {
+				t.Fatal(err)
+			}
+			if tc.pos != nil {
+				d.SetPos(tc.pos[0], tc.pos[1])
+			}
+			blocks, ctx, err := d.GeneratePageBlocks(componentContext(1, tc.y))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(blocks) != tc.blocks {
+				t.Errorf("expected %d page blocks, got %d", tc.blocks, len(blocks))
+			}
+			if ctx.Page != tc.page || math.Abs(ctx.Y-tc.y2) > 1 {
+				t.Errorf("expected the context at page %d y %g, got page %d y %g", tc.page, tc.y2, ctx.Page, ctx.Y)
+			}
+		})
+	}
+}
+
+func TestGenerateComponentBlocksError(t *testing.T) {
+	d := newTestDocument(t, &fakeConverter{err: errors.New("render failed")})
+	if _, err := d.ContainerComponent(creator.New().NewDivision()); err != nil {
+		t.Fatal(err)
+	}
+	d.SetPos(100, 300)
+	_, ctx, err := d.GeneratePageBlocks(componentContext(1, 400))
+	if err == nil {
+		t.Fatal("expected the render error")
+	}
+	if ctx.Page != 1 || ctx.X != 50 || ctx.Y != 400 || ctx.Height != 350 {
+		t.Errorf("expected the caller's context on error, got page %d at (%g, %g)", ctx.Page, ctx.X, ctx.Y)
+	}
+}
+
+func TestFragment(t *testing.T) {
+	testCases := []struct {
+		name          string
+		pages         []testPage
+		width, height float64
+		err           error
+	}{
+		{name: "cropped", pages: []testPage{{300, 400, 100}}, width: 300, height: 100},
+		{name: "too tall", pages: []testPage{{300, 400, 400}, {300, 400, 100}}, err: ErrFragmentTooTall},
+		{name: "no visible content", pages: []testPage{{300, 400, 0}}, err: errFragmentEmpty},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := newTestDocument(t, &fakeConverter{data: newTestPages(t, tc.pages...)})
+			block, err := d.Fragment(context.Background(), sizes.Point(300))
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("expected error %v, got %v", tc.err, err)
+			}
+			if err != nil {
+				return
+			}
+			if math.Abs(block.Width()-tc.width) > 1 || math.Abs(block.Height()-tc.height) > 1 {
+				t.Errorf("expected the %gx%g block, got %gx%g", tc.width, tc.height, block.Width(), block.Height())
+			}
+		})
+	}
+}
@@ -4,9 +4,17 @@ import (
 	"archive/zip"
 	"bytes"
 	"fmt"
+	"io/fs"
 	"net/url"
 	"os"
+	"path"
+	"path/filepath"
 	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer/html"
 )
 
 // Content is an interface used for putting the content into Client Query.
@@ -110,6 +118,59 @@ func NewZipDirectory(dirPath string) (Content, error) {
 	return zd, nil
 }
 
+// NewFS creates new zip compressed file that recursively reads the 'root' directory of the file system,
+// i.e. the embed.FS. The zip entries are relative to the root directory.
+func NewFS(fsys fs.FS, root string) (Content, error) {
+	root = path.Clean(root)
+	zd := &zipDirectory{buffer: bytes.Buffer{}}
+	zd.writer = zip.NewWriter(&zd.buffer)
+
+	if err := zd.zipFS(fsys, root); err != nil {
+		return nil, err
+	}
+	if err := zd.writer.Close(); err != nil {
+		return nil, err
+	}
+	return zd, nil
+}
+
+func (z *zipDirectory) zipFS(fsys fs.FS, root string) error {
+	return fs.WalkDir(fsys, root, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return fmt.Errorf("reading directory '%s' failed: %w", filePath, err)
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		zipPath := filePath
+		if root != "." {
+			zipPath = strings.TrimPrefix(filePath, root+"/")
+		}
+
+		data, err := fs.ReadFile(fsys, filePath)
+		if err != nil {
+			return err
+		}
+		writer, err := z.writer.Create(zipPath)
+		if err != nil {
+			return err
+		}
+		_, err = writer.Write(data)
+		return err
+	})
+}
+
+// NewHTMLFileFS creates new Content htmlFile for provided path within the file system.
+func NewHTMLFileFS(fsys fs.FS, path string) (Content, error) {
+	data, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return nil, err
+	}
+	hf := &htmlFile{buffer: bytes.Buffer{}}
+	hf.buffer.Write(data)
+	return hf, nil
+}
+
 // -------------------- HTML FILE --------------------
 
 type htmlFile struct {
@@ -160,6 +221,71 @@ func (s *StringContent) ContentType() string { return "text/html" }
 // Data implements Content interface.
 func (s *StringContent) Data() []byte { return []byte(s.html) }
 
+// -------------------- MARKDOWN --------------------
+
+// DefaultMarkdownStylesheet is the print stylesheet used for the Markdown content with no stylesheet provided.
+const DefaultMarkdownStylesheet = `body { font-family: "Helvetica Neue", Arial, sans-serif; font-size: 11pt; line-height: 1.5; color: #222; }
+h1, h2, h3, h4, h5, h6 { line-height: 1.25; break-after: avoid; page-break-after: avoid; }
+h1 { font-size: 20pt; border-bottom: 1px solid #ccc; padding-bottom: 4pt; }
+h2 { font-size: 16pt; }
+h3 { font-size: 13pt; }
+table { border-collapse: collapse; width: 100%; margin: 8pt 0; }
+th, td { border: 1px solid #ccc; padding: 4pt 6pt; text-align: left; }
+th { background: #f3f3f3; }
+tr, img, pre, blockquote { break-inside: avoid; page-break-inside: avoid; }
+pre { background: #f6f8fa; padding: 8pt; white-space: pre-wrap; font-size: 9pt; }
+code { font-family: "SFMono-Regular", Consolas, Menlo, monospace; }
+blockquote { border-left: 3pt solid #ddd; margin-left: 0; padding-left: 8pt; color: #555; }
+img { max-width: 100%; }
+.footnotes { font-size: 9pt; border-top: 1px solid #ccc; margin-top: 16pt; }`
+
+var markdownConverter = goldmark.New(
+	goldmark.WithExtensions(extension.Table, extension.Strikethrough, extension.TaskList, extension.Footnote),
+	goldmark.WithParserOptions(parser.WithAutoHeadingID()),
+	goldmark.WithRendererOptions(html.WithUnsafe()),
+)
+
+// NewMarkdown creates new Content that converts the CommonMark source, with tables, fenced code
+// and footnotes, into the HTML document styled with the print 'stylesheet'.
+// If the stylesheet is empty the DefaultMarkdownStylesheet is used.
+// The raw HTML within the source, including the scripts, is passed through unescaped, as it's rendered with
+// the html.WithUnsafe option. The untrusted Markdown needs to be sanitized before the conversion.
+func NewMarkdown(source []byte, stylesheet string) (*StringContent, error) {
+	if stylesheet == "" {
+		stylesheet = DefaultMarkdownStylesheet
+	}
+	body := bytes.Buffer{}
+	if err := markdownConverter.Convert(source, &body); err != nil {
+		return nil, fmt.Errorf("converting markdown failed: %w", err)
+	}
+
+	sb := strings.Builder{}
+	sb.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<style>\n")
+	sb.WriteString(stylesheet)
+	sb.WriteString("\n</style>\n</head>\n<body>\n")
+	sb.Write(body.Bytes())
+	sb.WriteString("</body>\n</html>\n")
+	return NewStringContent(sb.String())
+}
+
+// NewMarkdownFile creates new Markdown Content for provided input path.
+func NewMarkdownFile(path, stylesheet string) (*StringContent, error) {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewMarkdown(source, stylesheet)
+}
+
+// IsMarkdownFile checks if the path has one of the Markdown file extensions.
+func IsMarkdownFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".md", ".markdown":
+		return true
+	}
+	return false
+}
+
 // -------------------- WEB URL --------------------
 
 type webURL struct {
@@ -0,0 +1,196 @@
+package content
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"sort"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestNewMarkdown(t *testing.T) {
+	source := `# Report
+
+| Item | Qty |
+|------|----:|
+| Pen  |   2 |
+
+- [x] Done task
+- [ ] Open task
+
+Noted text[^1] and ~~removed~~.
+
+<span class="raw">raw html</span>
+
+[^1]: The footnote.
+`
+	c, err := NewMarkdown([]byte(source), "")
+	if err != nil {
+		t.Fatalf("NewMarkdown() failed: %v", err)
+	}
+	if c.Method() != "html" || c.ContentType() != "text/html" {
+		t.Errorf("unexpected method %q and content type %q", c.Method(), c.ContentType())
+	}
+	html := string(c.Data())
+
+	for _, want := range []string{
+		DefaultMarkdownStylesheet,
+		`<h1 id="report">Report</h1>`,
+		`<table>`,
+		`<th>Item</th>`,
+		`<td style="text-align:right">2</td>`,
+		`<input checked="" disabled="" type="checkbox"`,
+		`<input disabled="" type="checkbox"`,
+		`<sup id="fnref:1"><a href="#fn:1" class="footnote-ref" role="doc-noteref">1</a></sup>`,
+		`<div class="footnotes" role="doc-endnotes">`,
+		`The footnote.`,
+		`<del>removed</del>`,
+		`<span class="raw">raw html</span>`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("rendered HTML doesn't contain %q:\n%s", want, html)
+		}
+	}
+
+	styled, err := NewMarkdown([]byte("text"), "p { color: red; }")
+	if err != nil {
+		t.Fatalf("NewMarkdown() failed: %v", err)
+	}
+	if data := string(styled.Data()); !strings.Contains(data, "p { color: red; }") || strings.Contains(data, DefaultMarkdownStylesheet) {
+		t.Errorf("custom stylesheet not used:\n%s", data)
+	}
+}
+
+func TestIsMarkdownFile(t *testing.T) {
+	testCases := map[string]bool{
+		"README.md":     true,
+		"doc.Markdown":  true,
+		"index.html":    false,
+		"md":            false,
+		"dir.md/x.html": false,
+	}
+	for path, want := range testCases {
+		if got := IsMarkdownFile(path); got != want {
+			t.Errorf("IsMarkdownFile(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestNewFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"site/index.html":            {Data: []byte("<p>index</p>")},
+		"site/css/style.css":         {Data: []byte("p {}")},
+		"site/assets/img/logo.svg":   {Data: []byte("<svg/>")},
+		"site/assets/fonts/a/b.woff": {Data: []byte("font")},
+		"other/ignored.html":         {Data: []byte("ignored")},
+	}
+
+	testCases := []struct {
+		name    string
+		root    string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name: "nested root",
+			root: "site",
+			want: map[string]string{
+				"index.html":            "<p>index</p>",
+				"css/style.css":         "p {}",
+				"assets/img/logo.svg":   "<svg/>",
+				"assets/fonts/a/b.woff": "font",
+			},
+		},
+		{
+			name: "nested root with slash",
+			root: "site/assets/",
+			want: map[string]string{
+				"img/logo.svg":   "<svg/>",
+				"fonts/a/b.woff": "font",
+			},
+		},
+		{
+			name: "file system root",
+			want: map[string]string{
+				"site/index.html":            "<p>index</p>",
+				"site/css/style.css":         "p {}",
+				"site/assets/img/logo.svg":   "<svg/>",
+				"site/assets/fonts/a/b.woff": "font",
+				"other/ignored.html":         "ignored",
+			},
+		},
+		{name: "missing root", root: "missing", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := NewFS(fsys, tc.root)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("NewFS() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if c.Method() != "dir" || c.ContentType() != "application/zip" {
+				t.Errorf("unexpected method %q and content type %q", c.Method(), c.ContentType())
+			}
+			got := readZip(t, c.Data())
+			if len(got) != len(tc.want) {
+				t.Errorf("zip entries = %v, want %d entries", sortedKeys(got), len(tc.want))
+			}
+			for name, data := range tc.want {
+				if got[name] != data {
+					t.Errorf("zip entry %q = %q, want %q", name, got[name], data)
+				}
+			}
+		})
+	}
+}
+
+func TestNewHTMLFileFS(t *testing.T) {
+	fsys := fstest.MapFS{"web/pages/index.html": {Data: []byte("<p>nested</p>")}}
+
+	c, err := NewHTMLFileFS(fsys, "web/pages/index.html")
+	if err != nil {
+		t.Fatalf("NewHTMLFileFS() failed: %v", err)
+	}
+	if c.Method() != "html" || string(c.Data()) != "<p>nested</p>" {
+		t.Errorf("NewHTMLFileFS() = %q %q", c.Method(), c.Data())
+	}
+	if _, err = NewHTMLFileFS(fsys, "web/missing.html"); err == nil {
+		t.Error("NewHTMLFileFS() expected an error for the missing file")
+	}
+}
+
+func readZip(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("reading zip failed: %v", err)
+	}
+	files := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[f.Name] = string(content)
+	}
+	return files
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,115 @@
+package gohtml
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+
+	"github.com/unitechio/gopdf/core/security"
+	"github.com/unitechio/gopdf/model"
+)
+
+// EncryptionAlgorithm is the algorithm used to encrypt the PDF document.
+type EncryptionAlgorithm uint8
+
+// Encryption algorithms.
+const (
+	AES256 EncryptionAlgorithm = iota
+	AES128
+)
+
+// Permission is a set of the operations allowed for the user opening the document with the user password.
+type Permission uint16
+
+// Document permissions.
+const (
+	PermissionPrint Permission = 1 << iota
+	PermissionCopy
+	PermissionModify
+	PermissionAnnotate
+	PermissionFillForms
+	PermissionAssemble
+
+	PermissionAll = PermissionPrint | PermissionCopy | PermissionModify | PermissionAnnotate |
+		PermissionFillForms | PermissionAssemble
+)
+
+// Encryption are the options of the password protection of the PDF document.
+type Encryption struct {
+	// UserPassword is required to open the document. If empty, the document opens without a password,
+	// but the permissions still apply.
+	UserPassword string
+
+	// OwnerPassword grants the full access to the document. If empty, a random password is used,
+	// so that the permissions can't be lifted.
+	OwnerPassword string
+
+	// Algorithm defaults to the AES256.
+	Algorithm EncryptionAlgorithm
+
+	// Deny are the permissions denied to the user, i.e. PermissionPrint|PermissionCopy.
+	Deny Permission
+}
+
+// Validate checks if the encryption options are valid.
+func (e Encryption) Validate() error {
+	if e.Algorithm != AES256 && e.Algorithm != AES128 {
+		return errors.New("provided invalid encryption algorithm")
+	}
+	if e.Deny&^PermissionAll != 0 {
+		return errors.New("provided invalid encryption permissions")
+	}
+	if e.UserPassword != "" && e.UserPassword == e.OwnerPassword {
+		return errors.New("user and owner passwords must differ")
+	}
+	return nil
+}
+
+// Apply encrypts the output of the PDF writer.
+func (e Encryption) Apply(w *model.PdfWriter) error {
+	if err := e.Validate(); err != nil {
+		return err
+	}
+	owner := e.OwnerPassword
+	if owner == "" {
+		buf := make([]byte, 16)
+		if _, err := rand.Read(buf); err != nil {
+			return err
+		}
+		owner = hex.EncodeToString(buf)
+	}
+	opts := &model.EncryptOptions{Permissions: e.permissions(), Algorithm: model.AES_256bit}
+	if e.Algorithm == AES128 {
+		opts.Algorithm = model.AES_128bit
+	}
+	return w.Encrypt([]byte(e.UserPassword), []byte(owner), opts)
+}
+
+func (e Encryption) permissions() security.Permissions {
+	perms := security.PermOwner
+	for _, deny := range []struct {
+		permission Permission
+		flags      security.Permissions
+	}{
+		{PermissionPrint, security.PermPrinting | security.PermFullPrintQuality},
+		{PermissionCopy, security.PermExtractGraphics},
+		{PermissionModify, security.PermModify},
+		{PermissionAnnotate, security.PermAnnotate},
+		{PermissionFillForms, security.PermFillForms},
+		{PermissionAssemble, security.PermRotateInsert},
+	} {
+		if e.Deny&deny.permission != 0 {
+			perms &^= deny.flags
+		}
+	}
+	return perms
+}
+
+// SetEncryption sets the password protection of the output.
+func (d *Document) SetEncryption(e Encryption) error {
+	if err := e.Validate(); err != nil {
+		return err
+	}
+	d.encryption = &e
+	return nil
+}
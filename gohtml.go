@@ -5,13 +5,14 @@ import (
 	"bytes"
 	"context"
 	"errors"
-	"fmt"
-	"image"
-	"math"
+	"io"
+	"io/fs"
 	"net/url"
 	"os"
+	"sync"
 	"time"
 
+	"github.com/unitechio/gohtml/cache"
 	"github.com/unitechio/gohtml/client"
 	"github.com/unitechio/gohtml/content"
 	"github.com/unitechio/gohtml/selector"
@@ -19,7 +20,6 @@ import (
 	"github.com/unitechio/gopdf/common"
 	"github.com/unitechio/gopdf/creator"
 	"github.com/unitechio/gopdf/model"
-	"github.com/unitechio/gopdf/render"
 )
 
 // ===================== ERRORS =====================
@@ -27,25 +27,105 @@ import (
 var (
 	ErrNoClient          = errors.New("UniHTML client not found")
 	ErrContentNotDefined = errors.New("html document content not defined")
-	unihtmlClient        *client.Client
 )
 
+// ===================== CONVERTER =====================
+
+// Converter converts the HTML query into the PDF data. The *client.Client implements this interface.
+type Converter interface {
+	ConvertHTML(ctx context.Context, q *client.Query) (*client.PDFResponse, error)
+}
+
+var (
+	defaultMu     sync.RWMutex
+	unihtmlClient *client.Client
+)
+
+// DefaultConverter gets the converter set up by the Connect or ConnectOptions functions.
+// If no connection was established the function returns nil.
+func DefaultConverter() Converter {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	if unihtmlClient == nil {
+		return nil
+	}
+	return unihtmlClient
+}
+
+func setDefaultClient(c *client.Client) {
+	defaultMu.Lock()
+	unihtmlClient = c
+	defaultMu.Unlock()
+}
+
+// DocumentOption is a function that changes the Document setup.
+type DocumentOption func(d *Document)
+
+// WithCache sets the cache shared between the documents, that stores the rendered PDF data
+// keyed by a hash of the content and the query.
+func WithCache(c cache.Cache) DocumentOption {
+	return func(d *Document) { d.cache = c }
+}
+
+// WithConverter sets the converter used by the Document instead of the default one set up by the Connect function.
+func WithConverter(c Converter) DocumentOption {
+	return func(d *Document) { d.converter = c }
+}
+
 // ===================== DOCUMENT STRUCT =====================
 
 // Document is HTML document wrapper that is used for extracting and converting HTML document into PDF pages.
+// The output options, i.e. the metadata, encryption, signature, PDF/A level, letterhead and structure tree,
+// apply to the PDF written by the WriteToFile and Write functions only, not to the document drawn by the creator.
 type Document struct {
 	content     content.Content
+	converter   Converter
+	cache       cache.Cache
+	rendered    map[string]renderResult
+	ctx         context.Context
+	component   bool
+	width       sizes.Length
 	margins     margins
 	position    creator.Positioning
 	posX, posY  float64
+	hasPos      bool
 	pageSize    sizes.PageSize
 	pageWidth   sizes.Length
 	pageHeight  sizes.Length
 	orientation sizes.Orientation
-	trimLast    bool
+	trim        *TrimOptions
+	metadata    *Metadata
+	encryption  *Encryption
+	signature   *Signature
+	pdfa        PDFA
+	tagged      bool
+	structType  model.StructureType
+	mcid        *int64
+	marked      []markedContent
+	outline     bool
+	outlineSel  []string
+	watermarks  []Watermark
+	watermarked *Document
+	marks       []pageWatermark
+	letterhead  *Letterhead
+	chapter     *creator.Chapter
+	outlined    []*model.OutlineItem
 	waitTime    time.Duration
 	waitReady   []client.BySelector
 	waitVisible []client.BySelector
+	waitFunc    *client.WaitFunction
+	waitIdle    time.Duration
+	waitHidden  []client.BySelector
+	waitRemoved []client.BySelector
+	injectCSS   []client.Injection
+	injectJS    []client.Injection
+	disableJS   bool
+	failOnRes   bool
+	failOnHTTP  bool
+	diagnostics *client.Diagnostics
+	locale      string
+	timezone    string
+	fontFamily  string
 	timeout     *time.Duration
 }
 
@@ -60,14 +140,16 @@ type Options struct {
 	Hostname string
 	Port     int
 	Secure   bool
-	Prefix   string
+
+	// Prefix is the path prefix of the server endpoints, i.e. the path the server is proxied at.
+	Prefix string
 }
 
 // ===================== CONSTRUCTORS =====================
 
 // NewDocument creates new HTML Document used as an input for the creator.Drawable.
-func NewDocument(path string) (*Document, error) {
-	doc := &Document{}
+func NewDocument(path string, opts ...DocumentOption) (*Document, error) {
+	doc := newDocument(nil, opts)
 
 	u, err := url.Parse(path)
 	if err != nil {
@@ -88,9 +170,12 @@ func NewDocument(path string) (*Document, error) {
 		return nil, err
 	}
 
-	if !stat.IsDir() {
+	switch {
+	case !stat.IsDir() && content.IsMarkdownFile(path):
+		doc.content, err = content.NewMarkdownFile(path, "")
+	case !stat.IsDir():
 		doc.content, err = content.NewHTMLFile(path)
-	} else {
+	default:
 		doc.content, err = content.NewZipDirectory(path)
 	}
 	if err != nil {
@@ -100,58 +185,164 @@ func NewDocument(path string) (*Document, error) {
 }
 
 // NewDocumentFromString creates a new Document from the provided HTML string.
-func NewDocumentFromString(html string) (*Document, error) {
+func NewDocumentFromString(html string, opts ...DocumentOption) (*Document, error) {
 	c, err := content.NewStringContent(html)
 	if err != nil {
 		return nil, err
 	}
-	return &Document{content: c}, nil
+	return newDocument(c, opts), nil
+}
+
+// NewDocumentFromFS creates new HTML Document from the path within the file system, i.e. the embed.FS.
+// If the path is a directory its content is zipped and rendered like the NewDocument directory.
+func NewDocumentFromFS(fsys fs.FS, path string, opts ...DocumentOption) (*Document, error) {
+	stat, err := fs.Stat(fsys, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var c content.Content
+	switch {
+	case stat.IsDir():
+		c, err = content.NewFS(fsys, path)
+	case content.IsMarkdownFile(path):
+		var source []byte
+		if source, err = fs.ReadFile(fsys, path); err == nil {
+			c, err = content.NewMarkdown(source, "")
+		}
+	default:
+		c, err = content.NewHTMLFileFS(fsys, path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newDocument(c, opts), nil
+}
+
+// NewDocumentFromMarkdown creates a new Document from the CommonMark source, styled with the print stylesheet.
+// If the stylesheet is empty the content.DefaultMarkdownStylesheet is used.
+func NewDocumentFromMarkdown(source []byte, stylesheet string, opts ...DocumentOption) (*Document, error) {
+	c, err := content.NewMarkdown(source, stylesheet)
+	if err != nil {
+		return nil, err
+	}
+	return newDocument(c, opts), nil
+}
+
+func newDocument(c content.Content, opts []DocumentOption) *Document {
+	d := &Document{content: c}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
 }
 
 // ===================== CONNECTION =====================
 
-// Connect creates UniHTML HTTP Client and tries to establish connection with the server.
+// Connect creates UniHTML HTTP Client and tries to establish connection with the server. The client becomes
+// the default converter only once the server health check succeeds, so that the failed connection keeps the previous one.
 func Connect(path string) error {
 	opts, err := client.ParseOptions(path)
 	if err != nil {
 		return err
 	}
-	unihtmlClient = client.New(opts)
+	cli := client.New(opts)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	if err := unihtmlClient.HealthCheck(ctx); err != nil {
+	if err := cli.HealthCheck(ctx); err != nil {
 		return err
 	}
+	setDefaultClient(cli)
 	return nil
 }
 
 // ConnectOptions creates UniHTML HTTP Client and tries to establish connection with the server.
+// Like the Connect, it sets the default converter only once the server health check succeeds.
 func ConnectOptions(o Options) error {
-	unihtmlClient = client.New(client.Options{Hostname: o.Hostname, Port: o.Port, HTTPS: o.Secure})
+	cli := client.New(client.Options{Hostname: o.Hostname, Port: o.Port, HTTPS: o.Secure, Prefix: o.Prefix})
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	if err := unihtmlClient.HealthCheck(ctx); err != nil {
+	if err := cli.HealthCheck(ctx); err != nil {
 		return err
 	}
+	setDefaultClient(cli)
 	return nil
 }
 
 // ===================== DOCUMENT METHODS =====================
 
 func (d *Document) validate() error {
-	if unihtmlClient == nil {
+	if d.getConverter() == nil {
 		return ErrNoClient
 	}
 	if d.content == nil {
 		return ErrContentNotDefined
 	}
+	if d.outline && len(d.outlineSel) > 0 && d.disableJS {
+		return ErrOutlineJavaScript
+	}
 	return nil
 }
 
+// getConverter gets the converter of the document, or of the document it watermarks if not set.
+func (d *Document) getConverter() Converter {
+	for _, doc := range d.lineage() {
+		if !isNilConverter(doc.converter) {
+			return doc.converter
+		}
+	}
+	return DefaultConverter()
+}
+
+// getCache gets the cache of the document, or of the document it watermarks if not set.
+func (d *Document) getCache() cache.Cache {
+	for _, doc := range d.lineage() {
+		if doc.cache != nil {
+			return doc.cache
+		}
+	}
+	return nil
+}
+
+// lineage gets the document followed by the documents it's the watermark of, up to the watermarked one.
+func (d *Document) lineage() []*Document {
+	var docs []*Document
+	seen := map[*Document]struct{}{}
+	for doc := d; doc != nil; doc = doc.watermarked {
+		if _, ok := seen[doc]; ok {
+			break
+		}
+		seen[doc] = struct{}{}
+		docs = append(docs, doc)
+	}
+	return docs
+}
+
+// isNilConverter checks if the converter is nil, including the nil *client.Client stored in the interface.
+func isNilConverter(c Converter) bool {
+	switch t := c.(type) {
+	case nil:
+		return true
+	case *client.Client:
+		return t == nil
+	}
+	return false
+}
+
 func (d *Document) GetContent() content.Content { return d.content }
 
+// SetConverter sets the converter used by the Document instead of the default one.
+func (d *Document) SetConverter(c Converter) { d.converter = c }
+
+// SetCache sets the cache shared between the documents for storing the rendered PDF data.
+func (d *Document) SetCache(c cache.Cache) { d.cache = c }
+
+// Invalidate drops the memoized renders of the document and its watermarks, so that the next layout
+// re-renders the content. The shared cache, if set, is not affected.
+func (d *Document) Invalidate() { d.rendered, d.marks = nil, nil }
+
 func (d *Document) SetMargins(left, right, top, bottom float64) {
 	d.margins.Left = sizes.Point(left)
 	d.margins.Right = sizes.Point(right)
@@ -190,60 +381,169 @@ func (d *Document) SetLandscapeOrientation() {
 	d.orientation = sizes.Landscape
 }
 
+// SetPos sets the absolute position of the document on the page. Within the creator containers other than
+// the creator.Chapter, i.e. the creator.Division, the document is drawn at the position on the current page
+// and doesn't move the container's content that follows it.
 func (d *Document) SetPos(x, y float64) {
 	d.position = creator.PositionAbsolute
 	d.posX, d.posY = x, y
+	d.hasPos = true
 }
 
+// SetTimeoutDuration sets the server timeout for the document conversion. It overrides the default
+// timeout budget computed from the document wait conditions.
 func (d *Document) SetTimeoutDuration(duration time.Duration) { d.timeout = &duration }
+
+// SetContext sets the context used by the GeneratePageBlocks when the Document is drawn by the creator.
+// The context allows to cancel the conversion, and its deadline limits the document timeout budget.
+func (d *Document) SetContext(ctx context.Context) { d.ctx = ctx }
+
+func (d *Document) getContext() context.Context {
+	if d.ctx != nil {
+		return d.ctx
+	}
+	return context.Background()
+}
+
+// defaultRenderTimeout is the server time budget for rendering the page, on top of the document wait conditions.
+const defaultRenderTimeout = 15 * time.Second
+
+// getTimeoutDuration gets the server timeout budget, either the SetTimeoutDuration or the default render
+// timeout extended by the wait conditions.
 func (d *Document) getTimeoutDuration() time.Duration {
 	if d.timeout != nil {
 		return *d.timeout
 	}
-	return 0
+	timeout := defaultRenderTimeout + d.waitTime + d.waitIdle
+	if d.waitFunc != nil {
+		timeout += d.waitFunc.Timeout
+	}
+	return timeout
+}
+
+// serverTimeout gets the timeout budget limited by the context deadline, less the client.ResponseTimeout.
+func (d *Document) serverTimeout(ctx context.Context) (time.Duration, error) {
+	timeout := d.getTimeoutDuration()
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return timeout, nil
+	}
+	left := time.Until(deadline)
+	if left <= 0 {
+		return 0, context.DeadlineExceeded
+	}
+	if left -= client.ResponseTimeout; left <= 0 {
+		// There is no time left for the server error, the context deadline cuts off the request.
+		left = time.Until(deadline)
+	}
+	return min(timeout, left), nil
 }
 
 func (d *Document) WaitTime(duration time.Duration) { d.waitTime = duration }
-func (d *Document) TrimLastPageContent()            { d.trimLast = true }
 
 func (d *Document) WaitReady(sel string, by ...selector.ByType) {
-	byType := selector.BySearch
-	if len(by) > 0 {
-		byType = by[0]
-	}
-	d.waitReady = append(d.waitReady, client.BySelector{Selector: sel, By: byType})
+	d.waitReady = append(d.waitReady, newBySelector(sel, by))
 }
 
 func (d *Document) WaitVisible(sel string, by ...selector.ByType) {
-	byType := selector.BySearch
-	if len(by) > 0 {
-		byType = by[0]
-	}
-	d.waitVisible = append(d.waitVisible, client.BySelector{Selector: sel, By: byType})
+	d.waitVisible = append(d.waitVisible, newBySelector(sel, by))
+}
+
+// WaitHidden makes the renderer wait until the selector gets hidden or removed, i.e. a loading spinner.
+func (d *Document) WaitHidden(sel string, by ...selector.ByType) {
+	d.waitHidden = append(d.waitHidden, newBySelector(sel, by))
+}
+
+// WaitNotPresent makes the renderer wait until the selector is removed from the DOM.
+func (d *Document) WaitNotPresent(sel string, by ...selector.ByType) {
+	d.waitRemoved = append(d.waitRemoved, newBySelector(sel, by))
+}
+
+// WaitNetworkIdle makes the renderer wait until there is no network activity for the provided duration.
+func (d *Document) WaitNetworkIdle(duration time.Duration) { d.waitIdle = duration }
+
+// WaitFunction makes the renderer poll the JavaScript expression every pollInterval until it evaluates
+// to a truthy value, i.e. "window.renderComplete === true". The timeout limits the polling time.
+func (d *Document) WaitFunction(expression string, pollInterval, timeout time.Duration) {
+	d.waitFunc = &client.WaitFunction{Expression: expression, PollInterval: pollInterval, Timeout: timeout}
+}
+
+// InjectCSS injects the inline stylesheet into the page after it's loaded, i.e. to hide a cookie banner.
+func (d *Document) InjectCSS(css string) {
+	d.injectCSS = append(d.injectCSS, client.Injection{Content: css})
+}
+
+// InjectCSSFile injects the stylesheet file at the path within the directory content.
+func (d *Document) InjectCSSFile(path string) {
+	d.injectCSS = append(d.injectCSS, client.Injection{Path: path})
+}
+
+// InjectJS executes the inline script on the page after it's loaded and before the wait conditions.
+func (d *Document) InjectJS(js string) {
+	d.injectJS = append(d.injectJS, client.Injection{Content: js})
 }
 
+// InjectJSFile executes the script file at the path within the directory content.
+func (d *Document) InjectJSFile(path string) {
+	d.injectJS = append(d.injectJS, client.Injection{Path: path})
+}
+
+// DisableJavaScript disables JavaScript execution, i.e. for the untrusted static HTML.
+func (d *Document) DisableJavaScript() { d.disableJS = true }
+
+// FailOnResourceError makes the conversion fail when any of the page sub-resources failed to load.
+func (d *Document) FailOnResourceError() { d.failOnRes = true }
+
+// FailOnHTTPError makes the conversion fail when the web URL document returns a non-2xx status.
+func (d *Document) FailOnHTTPError() { d.failOnHTTP = true }
+
+// Diagnostics gets the browser diagnostics collected during the last document rendering. The memoized renders
+// keep their diagnostics, while the renders read from the shared cache have no diagnostics.
+func (d *Document) Diagnostics() *client.Diagnostics { return d.diagnostics }
+
+// SetLocale sets the BCP 47 language tag used by the page scripts for the date and number formatting.
+func (d *Document) SetLocale(locale string) { d.locale = locale }
+
+// SetTimezone sets the IANA time zone name, i.e. "Asia/Ho_Chi_Minh", used by the page scripts.
+func (d *Document) SetTimezone(timezone string) { d.timezone = timezone }
+
+// SetDefaultFontFamily sets the font family used for the text with no font family defined.
+func (d *Document) SetDefaultFontFamily(family string) { d.fontFamily = family }
+
 // ===================== EXPORT =====================
 
+// WriteToFile converts the document and writes it as a PDF file at the outputPath.
 func (d *Document) WriteToFile(outputPath string) error {
-	if err := d.validate(); err != nil {
+	return d.WriteToFileContext(d.getContext(), outputPath)
+}
+
+// WriteToFileContext converts the document with provided context and writes it as a PDF file at the outputPath.
+func (d *Document) WriteToFileContext(ctx context.Context, outputPath string) error {
+	if err := d.validateOutput(); err != nil {
 		return err
 	}
-	timeout := 20*time.Second + d.waitTime
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
-
-	pages, err := d.extract(ctx, d.pageWidth, d.pageHeight, d.getMargins())
+	f, err := os.Create(outputPath)
 	if err != nil {
 		return err
 	}
+	if err = d.writePDF(ctx, f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
 
-	c := creator.New()
-	for _, p := range pages {
-		if err := c.AddPage(p); err != nil {
-			return err
-		}
+// Write converts the document and writes it as a PDF into the writer.
+func (d *Document) Write(w io.Writer) error {
+	return d.WriteContext(d.getContext(), w)
+}
+
+// WriteContext converts the document with provided context and writes it as a PDF into the writer.
+func (d *Document) WriteContext(ctx context.Context, w io.Writer) error {
+	if err := d.validateOutput(); err != nil {
+		return err
 	}
-	return c.WriteToFile(outputPath)
+	return d.writePDF(ctx, w)
 }
 
 func (d *Document) GetPdfPages(ctx context.Context) ([]*model.PdfPage, error) {
@@ -280,6 +580,87 @@ func (d *Document) getMargins() margins {
 }
 
 func (d *Document) extract(ctx context.Context, w, h sizes.Length, m margins) ([]*model.PdfPage, error) {
+	pdfReader, err := d.read(ctx, w, h, m)
+	if err != nil {
+		return nil, err
+	}
+	return pdfReader.PageList, nil
+}
+
+// read renders the document and opens the rendered PDF.
+func (d *Document) read(ctx context.Context, w, h sizes.Length, m margins) (*model.PdfReader, error) {
+	data, err := d.render(ctx, w, h, m)
+	if err != nil {
+		return nil, err
+	}
+	return model.NewPdfReader(bytes.NewReader(data))
+}
+
+// render gets the PDF data for provided page dimensions. The result is memoized by the query hash, so that
+// repeated layout of the document doesn't require another server round trip.
+func (d *Document) render(ctx context.Context, w, h sizes.Length, m margins) ([]byte, error) {
+	// The diagnostics of the previous render are not reported for the failed one.
+	d.diagnostics = nil
+	req, err := d.buildQuery(w, h, m)
+	if err != nil {
+		return nil, err
+	}
+	key, err := req.Hash()
+	if err != nil {
+		return nil, err
+	}
+	if res, ok := d.rendered[key]; ok {
+		common.Log.Trace("Using memoized document render: %s", key)
+		d.diagnostics = res.diagnostics
+		return res.data, nil
+	}
+	c := d.getCache()
+	if c != nil {
+		if data, ok := c.Get(key); ok {
+			common.Log.Trace("Using cached document render: %s", key)
+			// The shared cache stores the PDF data only, the diagnostics of the render are not known.
+			d.memoize(key, renderResult{data: data})
+			return data, nil
+		}
+	}
+
+	// The timeout is not a part of the query hash, so that it's set once the render is not found.
+	if req.TimeoutDuration, err = d.serverTimeout(ctx); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, req.TimeoutDuration+client.ResponseTimeout)
+	defer cancel()
+
+	resp, err := d.getConverter().ConvertHTML(ctx, req)
+	if err != nil {
+		var diagErr *client.DiagnosticsError
+		if errors.As(err, &diagErr) {
+			d.diagnostics = diagErr.Diagnostics
+		}
+		return nil, err
+	}
+	d.diagnostics = resp.Diagnostics
+	d.memoize(key, renderResult{data: resp.Data, diagnostics: resp.Diagnostics})
+	if c != nil {
+		c.Set(key, resp.Data)
+	}
+	return resp.Data, nil
+}
+
+// renderResult is the memoized PDF data with the browser diagnostics of its render.
+type renderResult struct {
+	data        []byte
+	diagnostics *client.Diagnostics
+}
+
+func (d *Document) memoize(key string, res renderResult) {
+	if d.rendered == nil {
+		d.rendered = map[string]renderResult{}
+	}
+	d.rendered[key] = res
+}
+
+func (d *Document) buildQuery(w, h sizes.Length, m margins) (*client.Query, error) {
 	query := client.BuildHTMLQuery().
 		SetContent(d.content).
 		PageSize(d.pageSize).
@@ -291,7 +672,10 @@ func (d *Document) extract(ctx context.Context, w, h sizes.Length, m margins) ([
 		MarginTop(m.Top).
 		MarginBottom(m.Bottom).
 		TimeoutDuration(d.getTimeoutDuration()).
-		WaitTime(d.waitTime)
+		WaitTime(d.waitTime).
+		Locale(d.locale).
+		Timezone(d.timezone).
+		DefaultFontFamily(d.fontFamily)
 
 	for _, sel := range d.waitReady {
 		query.WaitReady(sel.Selector, sel.By)
@@ -299,51 +683,91 @@ func (d *Document) extract(ctx context.Context, w, h sizes.Length, m margins) ([
 	for _, sel := range d.waitVisible {
 		query.WaitVisible(sel.Selector, sel.By)
 	}
-
-	req, err := query.Query()
-	if err != nil {
-		return nil, err
+	for _, sel := range d.waitHidden {
+		query.WaitHidden(sel.Selector, sel.By)
 	}
-
-	var cancel context.CancelFunc
-	if d.timeout != nil {
-		ctx, cancel = context.WithTimeout(ctx, *d.timeout)
-	} else {
-		ctx, cancel = context.WithTimeout(ctx, 15*time.Second)
+	for _, sel := range d.waitRemoved {
+		query.WaitNotPresent(sel.Selector, sel.By)
 	}
-	defer cancel()
-
-	resp, err := unihtmlClient.ConvertHTML(ctx, req)
-	if err != nil {
-		return nil, err
+	for _, inj := range d.injectCSS {
+		if inj.Path != "" {
+			query.InjectCSSFile(inj.Path)
+		} else {
+			query.InjectCSS(inj.Content)
+		}
 	}
-
-	reader := bytes.NewReader(resp.Data)
-	pdfReader, err := model.NewPdfReader(reader)
-	if err != nil {
-		return nil, err
+	for _, inj := range d.injectJS {
+		if inj.Path != "" {
+			query.InjectJSFile(inj.Path)
+		} else {
+			query.InjectJS(inj.Content)
+		}
 	}
-	return pdfReader.PageList, nil
+	if d.disableJS {
+		query.DisableJavaScript()
+	}
+	if d.failOnRes {
+		query.FailOnResourceError()
+	}
+	if d.failOnHTTP {
+		query.FailOnHTTPError()
+	}
+	if d.tagged {
+		query.TaggedPDF()
+	}
+	if d.outline {
+		query.DocumentOutline()
+		if len(d.outlineSel) > 0 {
+			script, err := outlineScript(d.outlineSel)
+			if err != nil {
+				return nil, err
+			}
+			query.InjectJS(script)
+		}
+	}
+	if d.waitIdle != 0 {
+		query.WaitNetworkIdle(d.waitIdle)
+	}
+	if d.waitFunc != nil {
+		query.WaitFunction(d.waitFunc.Expression, d.waitFunc.PollInterval, d.waitFunc.Timeout)
+	}
+
+	return query.Query()
 }
 
 // ===================== INTERFACE IMPLEMENTATIONS =====================
 
 // Implements creator.Drawable
 func (d *Document) ContainerComponent(container creator.Drawable) (creator.Drawable, error) {
-	switch container.(type) {
+	switch c := container.(type) {
 	case *creator.Chapter:
+		d.component = false
+		d.chapter = c
 	default:
-		return nil, fmt.Errorf("unihtml.Document can't be a component of the %T container", container)
+		d.chapter = nil
+		// Within the other containers i.e. creator.Division the document is rendered at the container's
+		// width and takes the height of its rendered content.
+		d.component = true
 	}
 	return d, nil
 }
 
 // Implements creator.Drawable
 func (d *Document) GeneratePageBlocks(ctx creator.DrawContext) ([]*creator.Block, creator.DrawContext, error) {
+	return d.GeneratePageBlocksContext(d.getContext(), ctx)
+}
+
+// GeneratePageBlocksContext generates the page blocks for the draw context, converting the document
+// with provided context.
+func (d *Document) GeneratePageBlocksContext(cctx context.Context, ctx creator.DrawContext) ([]*creator.Block, creator.DrawContext, error) {
 	if err := d.validate(); err != nil {
 		return nil, ctx, err
 	}
-	var blocks []*creator.Block
+	// The marked content of the previous layout is replaced.
+	d.marked = nil
+	if d.component {
+		return d.generateComponentBlocks(cctx, ctx)
+	}
 	m := d.getMargins()
 	w, h := d.pageWidth, d.pageHeight
 
@@ -354,85 +778,69 @@ func (d *Document) GeneratePageBlocks(ctx creator.DrawContext) ([]*creator.Block
 		ctx.X, ctx.Y = d.posX, d.posY
 	}
 
-	pages, err := d.extract(context.Background(), w, h, m)
+	reader, err := d.read(cctx, w, h, m)
+	if err != nil {
+		return nil, creator.DrawContext{}, err
+	}
+	pages := reader.PageList
+	links, err := newPageLinks(reader, true)
 	if err != nil {
 		return nil, creator.DrawContext{}, err
 	}
 
+	pb := pageBlocks{start: ctx.Page}
+	var placements []pagePlacement
 	for i, p := range pages {
+		if d.trimPage(i, len(pages)) {
+			if p, err = d.trimContent(p); err != nil {
+				return nil, creator.DrawContext{}, err
+			}
+		}
+		if p, err = d.markContent(p, ctx.Page); err != nil {
+			return nil, creator.DrawContext{}, err
+		}
 		block, err := creator.NewBlockFromPage(p)
 		if err != nil {
 			return nil, creator.DrawContext{}, err
 		}
-		var trimHeight float64
-		if d.trimLast && i == len(pages)-1 {
-			imgDev := render.NewImageDevice()
-			img, err := imgDev.Render(p)
-			if err != nil {
-				return nil, creator.DrawContext{}, err
-			}
-			mbox, err := p.GetMediaBox()
-			if err != nil {
-				return nil, creator.DrawContext{}, err
-			}
-			start := time.Now()
-			trimRatio := detectTrimHeight(img)
-			trimHeight = mbox.Height() * trimRatio
-			common.Log.Trace("Trimming last document page taken: %v", time.Since(start))
-			if d.margins.Bottom != nil {
-				trimHeight -= float64(d.margins.Bottom.Points())
-			}
-			if trimHeight < 0 {
-				trimHeight = 0
-			}
-			common.Log.Trace("Cropping document's page %.2f points off bottom of media box", trimHeight)
-		}
-		pageBlocks, newCtx, err := block.GeneratePageBlocks(ctx)
+		box, err := p.GetMediaBox()
 		if err != nil {
 			return nil, creator.DrawContext{}, err
 		}
-		ctx = newCtx
-		ctx.Y -= trimHeight
+		links.add(block, i, *box)
+		if err = pb.draw(ctx, block); err != nil {
+			return nil, creator.DrawContext{}, err
+		}
+		placements = append(placements, pagePlacement{source: i, page: int64(ctx.Page - 1), x: ctx.X, y: ctx.Y, box: *box})
+		ctx.Y += block.Height()
+		ctx.Height -= block.Height()
 		if i != len(pages)-1 && ctx.Y > (ctx.PageHeight-ctx.Margins.Bottom)*.95 {
 			ctx.X = ctx.Margins.Left
 			ctx.Y = ctx.Margins.Top
+			ctx.Height = ctx.PageHeight - ctx.Margins.Top - ctx.Margins.Bottom
 			ctx.Page++
 		}
-		blocks = append(blocks, pageBlocks...)
 	}
-	return blocks, ctx, nil
-}
 
-// Unused methods just to satisfy interface
-func (d *Document) GenerateKDict() (*model.KDict, error)   { return nil, nil }
-func (d *Document) SetStructureType(t model.StructureType) {}
-func (d *Document) SetMarkedContentID(id int64)            {}
+	links.place(placements, ctx.PageHeight)
+
+	if d.outline {
+		if err = d.addOutline(reader, placements); err != nil {
+			return nil, creator.DrawContext{}, err
+		}
+	}
+	if err = d.drawWatermarks(cctx, pb); err != nil {
+		return nil, creator.DrawContext{}, err
+	}
+	return pb.blocks, ctx, nil
+}
 
 // ===================== HELPERS =====================
 
-func detectTrimHeight(img image.Image) float64 {
-	bounds := img.Bounds()
-	var lastNonEmptyY int
-	ref := img.At(bounds.Min.X, bounds.Max.Y-1)
-	r, g, b, _ := ref.RGBA()
-	isWhite := r == math.MaxUint16 && g == math.MaxUint16 && b == math.MaxUint16
-
-	for y := bounds.Max.Y - 1; y >= bounds.Min.Y; y-- {
-		var rowHasContent bool
-		for x := bounds.Min.X; x < bounds.Max.X; x++ {
-			cr, cg, cb, _ := img.At(x, y).RGBA()
-			if (isWhite && !(cr == r && cg == g && cb == b)) ||
-				(!isWhite && (math.Abs(float64(cr)-float64(r))/float64(math.MaxUint16) > 0.03 ||
-					math.Abs(float64(cg)-float64(g))/float64(math.MaxUint16) > 0.03 ||
-					math.Abs(float64(cb)-float64(b))/float64(math.MaxUint16) > 0.03)) {
-				rowHasContent = true
-				break
-			}
-		}
-		if rowHasContent {
-			break
-		}
-		lastNonEmptyY = y
+func newBySelector(sel string, by []selector.ByType) client.BySelector {
+	byType := selector.BySearch
+	if len(by) > 0 {
+		byType = by[0]
 	}
-	return float64(bounds.Max.Y-lastNonEmptyY) / float64(bounds.Max.Y)
+	return client.BySelector{Selector: sel, By: byType}
 }
@@ -0,0 +1,399 @@
+package gohtml
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/unitechio/gohtml/cache"
+	"github.com/unitechio/gohtml/client"
+	"github.com/unitechio/gohtml/sizes"
+	"github.com/unitechio/gopdf/contentstream"
+	"github.com/unitechio/gopdf/core"
+	"github.com/unitechio/gopdf/creator"
+	"github.com/unitechio/gopdf/model"
+)
+
+// testPage is the page of the test PDF with the black box drawn at the top of the page
+// over the content height. The zero content height leaves the page empty.
+type testPage struct {
+	width, height, content float64
+}
+
+// newTestPages creates the PDF document with the pages.
+func newTestPages(t *testing.T, pages ...testPage) []byte {
+	t.Helper()
+	return writeTestPDF(t, newTestWriter(t, pages...))
+}
+
+// newTestWriter creates the PDF writer with the pages added.
+func newTestWriter(t *testing.T, pages ...testPage) *model.PdfWriter {
+	t.Helper()
+	w := model.NewPdfWriter()
+	for _, p := range pages {
+		page := model.NewPdfPage()
+		page.MediaBox = &model.PdfRectangle{Urx: p.width, Ury: p.height}
+		cc := contentstream.NewContentCreator()
+		if p.content > 0 {
+			cc.Add_rg(0, 0, 0).Add_re(0, p.height-p.content, p.width, p.content).Add_f()
+		}
+		if err := page.SetContentStreams([]string{cc.String()}, core.NewRawEncoder()); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.AddPage(page); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return &w
+}
+
+func writeTestPDF(t *testing.T, w *model.PdfWriter) []byte {
+	t.Helper()
+	buf := bytes.Buffer{}
+	if err := w.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// fakeConverter is the Converter responding with the fixed PDF data, or the error if set.
+// It counts the conversions and keeps the last query.
+type fakeConverter struct {
+	data        []byte
+	diagnostics *client.Diagnostics
+	err         error
+	calls       int
+	query       *client.Query
+}
+
+// ConvertHTML implements the Converter interface.
+func (f *fakeConverter) ConvertHTML(_ context.Context, q *client.Query) (*client.PDFResponse, error) {
+	f.calls++
+	f.query = q
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &client.PDFResponse{Data: f.data, Diagnostics: f.diagnostics}, nil
+}
+
+// newTestDocument creates the document converted by the converter.
+func newTestDocument(t *testing.T, conv Converter) *Document {
+	t.Helper()
+	d, err := NewDocumentFromString(`<html lang="en"><p>text</p></html>`, WithConverter(conv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+// readTestPDF writes the creator output and opens it.
+func readTestPDF(t *testing.T, write func(w *bytes.Buffer) error) *model.PdfReader {
+	t.Helper()
+	buf := bytes.Buffer{}
+	if err := write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	reader, err := model.NewPdfReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return reader
+}
+
+func TestCreatorStructTree(t *testing.T) {
+	conv := &fakeConverter{data: newTestPages(t, testPage{500, 700, 600}, testPage{500, 700, 300})}
+	d := newTestDocument(t, conv)
+	d.SetStructureType(model.StructureTypeArticle)
+	d.SetMarkedContentID(0)
+
+	c := creator.New()
+	ch := c.NewChapter("Chapter")
+	if err := ch.Add(d); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Draw(ch); err != nil {
+		t.Fatal(err)
+	}
+	c.SetOptimizer(NewCreatorStructTreeOptimizer(d))
+	reader := readTestPDF(t, func(w *bytes.Buffer) error { return c.Write(w) })
+
+	root, ok := reader.GetCatalogStructTreeRoot()
+	if !ok {
+		t.Fatal("expected the structure tree root")
+	}
+	if len(reader.PageList) != 2 {
+		t.Fatalf("expected the document drawn on 2 pages, got %d", len(reader.PageList))
+	}
+	rootDict := resolveDict(root)
+	parentTree := resolveDict(rootDict.Get("ParentTree"))
+	if parentTree == nil {
+		t.Fatal("expected the parent tree")
+	}
+	nums, ok := core.GetArray(core.ResolveReference(parentTree.Get("Nums")))
+	if !ok || nums.Len() != 2*len(reader.PageList) {
+		t.Fatalf("expected the parent tree entry of each of the %d pages, got %v", len(reader.PageList), nums)
+	}
+
+	for i, p := range reader.PageList {
+		key, ok := core.GetIntVal(p.StructParents)
+		if !ok {
+			t.Fatalf("expected the page %d structure parents key", i+1)
+		}
+		parents, _ := core.GetArray(core.ResolveReference(nums.Get(2*key + 1)))
+		if parents == nil || parents.Len() != 1 {
+			t.Fatalf("expected the page %d document element with MCID 0, got %v", i+1, parents)
+		}
+		elem := resolveDict(parents.Get(0))
+		if s, _ := core.GetNameVal(elem.Get("S")); s != "Art" {
+			t.Errorf("expected the Art element, got %s", s)
+		}
+		refs, _ := core.GetArray(core.ResolveReference(elem.Get("K")))
+		if refs == nil || refs.Len() != len(reader.PageList) {
+			t.Fatalf("expected the marked content reference on each page, got %v", refs)
+		}
+		pg, _ := core.GetIndirect(resolveDict(refs.Get(i)).Get("Pg"))
+		if pg != p.GetPageAsIndirectObject() {
+			t.Errorf("expected the reference %d to the page %d", i, i+1)
+		}
+	}
+}
+
+func TestDocumentRenderMemo(t *testing.T) {
+	ctx := context.Background()
+	conv := &fakeConverter{data: newTestPages(t, testPage{300, 842, 200})}
+	shared := cache.NewMemory(10)
+	d := newTestDocument(t, conv)
+	d.SetCache(shared)
+	d.SetWidth(sizes.Point(300))
+
+	render := func(step string, f func() error, expected int) {
+		t.Helper()
+		if err := f(); err != nil {
+			t.Fatalf("%s: %v", step, err)
+		}
+		if conv.calls != expected {
+			t.Errorf("%s: expected %d conversions, got %d", step, expected, conv.calls)
+		}
+	}
+	measure := func() error {
+		_, err := d.Measure(ctx, sizes.Point(300))
+		return err
+	}
+
+	render("measure", measure, 1)
+	render("draw", func() error {
+		c := creator.New()
+		div := c.NewDivision()
+		if err := div.Add(d); err != nil {
+			return err
+		}
+		return c.Draw(div)
+	}, 1)
+	render("height", func() error {
+		if h := d.Height(); h != 200 {
+			t.Errorf("expected height 200, got %g", h)
+		}
+		return nil
+	}, 1)
+	render("measure other width", func() error {
+		_, err := d.Measure(ctx, sizes.Point(200))
+		return err
+	}, 2)
+	render("locale changed", func() error {
+		d.SetLocale("vi-VN")
+		return measure()
+	}, 3)
+	render("locale measured again", measure, 3)
+	render("invalidated", func() error {
+		// With no shared cache the invalidated document is rendered again.
+		d.Invalidate()
+		d.SetCache(nil)
+		return measure()
+	}, 4)
+
+	other := newTestDocument(t, conv)
+	other.SetCache(shared)
+	render("shared cache", func() error {
+		_, err := other.Measure(ctx, sizes.Point(300))
+		return err
+	}, 4)
+}
+
+func TestDocumentDiagnostics(t *testing.T) {
+	consoleDiag := &client.Diagnostics{Console: []client.ConsoleMessage{{Level: "error", Text: "failed"}}}
+	testCases := []struct {
+		name        string
+		diagnostics *client.Diagnostics
+		err         error
+		expected    *client.Diagnostics
+	}{
+		{name: "rendered", diagnostics: consoleDiag, expected: consoleDiag},
+		{name: "diagnostics error", err: &client.DiagnosticsError{Err: errors.New("resource failed"), Diagnostics: consoleDiag}, expected: consoleDiag},
+		{name: "other error", err: errors.New("connection refused")},
+		{name: "other error again", err: errors.New("server timeout")},
+	}
+
+	conv := &fakeConverter{data: newTestPages(t, testPage{300, 842, 200})}
+	d := newTestDocument(t, conv)
+	for i, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			conv.diagnostics, conv.err = tc.diagnostics, tc.err
+			// Each of the renders is a new query, not read from the memoized renders.
+			_, err := d.Measure(context.Background(), sizes.Point(100+i))
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("expected error %v, got %v", tc.err, err)
+			}
+			if d.Diagnostics() != tc.expected {
+				t.Errorf("expected diagnostics %v, got %v", tc.expected, d.Diagnostics())
+			}
+		})
+	}
+}
+
+func TestGetTimeoutDuration(t *testing.T) {
+	testCases := []struct {
+		name     string
+		setup    func(d *Document)
+		expected time.Duration
+	}{
+		{name: "default", setup: func(d *Document) {}, expected: defaultRenderTimeout},
+		{
+			name: "wait conditions",
+			setup: func(d *Document) {
+				d.WaitTime(2 * time.Second)
+				d.WaitNetworkIdle(time.Second)
+				d.WaitFunction("window.ready", 100*time.Millisecond, 5*time.Second)
+			},
+			expected: defaultRenderTimeout + 8*time.Second,
+		},
+		{
+			name: "set timeout",
+			setup: func(d *Document) {
+				d.WaitTime(2 * time.Second)
+				d.SetTimeoutDuration(40 * time.Second)
+			},
+			expected: 40 * time.Second,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := &Document{}
+			tc.setup(d)
+			if got := d.getTimeoutDuration(); got != tc.expected {
+				t.Errorf("expected timeout %s, got %s", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestServerTimeout(t *testing.T) {
+	testCases := []struct {
+		name string
+		// deadline is the time left until the context deadline, zero for no deadline.
+		deadline time.Duration
+		expected time.Duration
+		err      bool
+	}{
+		{name: "no deadline", expected: defaultRenderTimeout},
+		{name: "distant deadline", deadline: time.Minute, expected: defaultRenderTimeout},
+		{name: "near deadline", deadline: 10 * time.Second, expected: 10*time.Second - client.ResponseTimeout},
+		{name: "no time for the server error", deadline: 3 * time.Second, expected: 3 * time.Second},
+		{name: "deadline passed", deadline: -time.Second, err: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tc.deadline != 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithDeadline(ctx, time.Now().Add(tc.deadline))
+				defer cancel()
+			}
+			got, err := (&Document{}).serverTimeout(ctx)
+			if tc.err {
+				if !errors.Is(err, context.DeadlineExceeded) {
+					t.Fatalf("expected the deadline exceeded error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			// The time left is measured once the context is created.
+			if got > tc.expected || got < tc.expected-time.Second {
+				t.Errorf("expected timeout %s, got %s", tc.expected, got)
+			}
+		})
+	}
+}
+
+// newTestServer starts the UniHTML server stub at the prefix, healthy if the healthy is set.
+// It records the paths of the received requests.
+func newTestServer(t *testing.T, prefix string, healthy bool, paths *[]string) Options {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*paths = append(*paths, r.URL.Path)
+		if !healthy || r.URL.Path != prefix+"/health" {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	port, err := strconv.Atoi(u.Port())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Options{Hostname: u.Hostname(), Port: port, Prefix: prefix}
+}
+
+func TestConnectOptions(t *testing.T) {
+	t.Cleanup(func() { setDefaultClient(nil) })
+
+	var paths []string
+	if err := ConnectOptions(newTestServer(t, "/unihtml", true, &paths)); err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 1 || paths[0] != "/unihtml/health" {
+		t.Errorf("expected the health check at the prefix, got %v", paths)
+	}
+	connected := DefaultConverter()
+	if connected == nil {
+		t.Fatal("expected the default converter")
+	}
+
+	// The failed connection keeps the default converter.
+	if err := ConnectOptions(newTestServer(t, "", false, &paths)); err == nil {
+		t.Fatal("expected the health check error")
+	}
+	if got := DefaultConverter(); got != connected {
+		t.Errorf("expected the default converter kept, got %v", got)
+	}
+}
+
+func TestDocumentConverter(t *testing.T) {
+	t.Cleanup(func() { setDefaultClient(nil) })
+
+	var paths []string
+	if err := ConnectOptions(newTestServer(t, "", true, &paths)); err != nil {
+		t.Fatal(err)
+	}
+	conv := &fakeConverter{data: newTestPages(t, testPage{500, 700, 300})}
+	d := newTestDocument(t, conv)
+	if err := d.Write(&bytes.Buffer{}); err != nil {
+		t.Fatal(err)
+	}
+	if conv.calls != 1 {
+		t.Errorf("expected the document converted by its converter, got %d conversions", conv.calls)
+	}
+	if len(paths) != 1 {
+		t.Errorf("expected no requests to the connected server but the health check, got %v", paths)
+	}
+}
@@ -0,0 +1,254 @@
+package gohtml
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+
+	"github.com/unitechio/gohtml/sizes"
+	"github.com/unitechio/gopdf/contentstream"
+	"github.com/unitechio/gopdf/core"
+	"github.com/unitechio/gopdf/creator"
+	"github.com/unitechio/gopdf/model"
+)
+
+// Names of the letterhead form XObjects in the page resources.
+const (
+	letterheadFirstName core.PdfObjectName = "LetterheadFirst"
+	letterheadRestName  core.PdfObjectName = "LetterheadRest"
+)
+
+// LetterheadOptions are the options of the letterhead pages.
+type LetterheadOptions struct {
+	// Rest is the document rendered as the background of the pages following the first one.
+	// If nil, the first page background is used on all of the pages.
+	Rest *Document
+
+	// FirstPageOnly draws the background on the first page only.
+	FirstPageOnly bool
+}
+
+// Letterhead is the page background rendered once from the HTML document and drawn under the content of
+// the pages of the creator documents or the Document outputs, i.e. the company letterhead of the letters.
+// The letterhead is aligned with the top left corner of the pages.
+type Letterhead struct {
+	first, rest *letterheadPage
+}
+
+// letterheadPage is the form XObject drawing the rendered letterhead page.
+type letterheadPage struct {
+	name core.PdfObjectName
+	form *core.PdfObjectStream
+	box  model.PdfRectangle
+}
+
+// NewLetterhead renders the first page of the document as the letterhead. The document is rendered at its
+// page size, with no margins unless they're set. The options define the background of the pages following
+// the first one.
+func NewLetterhead(ctx context.Context, first *Document, opts LetterheadOptions) (*Letterhead, error) {
+	if first == nil {
+		return nil, errors.New("letterhead document not defined")
+	}
+	if opts.FirstPageOnly && opts.Rest != nil {
+		return nil, errors.New("letterhead rest pages document defined for the first page only letterhead")
+	}
+	l := &Letterhead{}
+	var err error
+	if l.first, err = first.letterheadPage(ctx, letterheadFirstName); err != nil {
+		return nil, err
+	}
+	switch {
+	case opts.Rest != nil:
+		if l.rest, err = opts.Rest.letterheadPage(ctx, letterheadRestName); err != nil {
+			return nil, err
+		}
+	case !opts.FirstPageOnly:
+		l.rest = l.first
+	}
+	return l, nil
+}
+
+// letterheadPage renders the first page of the document as the letterhead form XObject with the name.
+func (d *Document) letterheadPage(ctx context.Context, name core.PdfObjectName) (*letterheadPage, error) {
+	if err := d.validate(); err != nil {
+		return nil, err
+	}
+	m := d.margins
+	for _, side := range []*sizes.Length{&m.Left, &m.Right, &m.Top, &m.Bottom} {
+		if *side == nil {
+			*side = sizes.Point(0)
+		}
+	}
+	reader, err := d.read(ctx, d.pageWidth, d.pageHeight, m)
+	if err != nil {
+		return nil, err
+	}
+	if len(reader.PageList) == 0 {
+		return nil, ErrContentNotDefined
+	}
+	page := reader.PageList[0]
+	box, err := page.GetMediaBox()
+	if err != nil {
+		return nil, err
+	}
+	form, err := pageForm(page)
+	if err != nil {
+		return nil, err
+	}
+	stream, ok := form.ToPdfObject().(*core.PdfObjectStream)
+	if !ok {
+		return nil, errors.New("letterhead form is not a stream")
+	}
+	return &letterheadPage{name: name, form: stream, box: *box}, nil
+}
+
+// Write writes the creator document with the letterhead drawn under the content of its pages. The letterhead
+// is chained after the optimizer the creator has at the time of writing, which is restored afterwards.
+func (l *Letterhead) Write(c *creator.Creator, w io.Writer) error {
+	o := c.GetOptimizer()
+	opts := optimizers{l}
+	if o != nil {
+		opts = optimizers{o, l}
+	}
+	c.SetOptimizer(opts)
+	defer c.SetOptimizer(o)
+	return c.Write(w)
+}
+
+// WriteToFile writes the creator document with the letterhead drawn under the content of its pages
+// into the file at the output path.
+func (l *Letterhead) WriteToFile(c *creator.Creator, outputPath string) error {
+	f, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+	if err = l.Write(c, f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// SetLetterhead sets the letterhead drawn under the pages of the output.
+func (d *Document) SetLetterhead(l *Letterhead) { d.letterhead = l }
+
+// Optimize implements the model.Optimizer interface. It prepends the letterhead content stream
+// to the content of the written pages. The letterhead forms are copied for each of the written documents,
+// as the PDF writer numbers the written objects.
+func (l *Letterhead) Optimize(objects []core.PdfObject) ([]core.PdfObject, error) {
+	catalog := findCatalog(objects)
+	if catalog == nil {
+		return objects, nil
+	}
+	copies := map[core.PdfObject]core.PdfObject{}
+	for i, page := range writtenPages(catalog) {
+		lp := l.rest
+		if i == 0 {
+			lp = l.first
+		}
+		dict, ok := core.GetDict(page)
+		if lp == nil || !ok {
+			continue
+		}
+		form, _ := copyObject(lp.form, copies).(*core.PdfObjectStream)
+		if err := lp.drawUnder(dict, form); err != nil {
+			return nil, err
+		}
+		objects = appendReferenced(objects, dict)
+	}
+	return objects, nil
+}
+
+// drawUnder adds the letterhead form to the page resources and draws it before the page content.
+// The letterhead is marked as the artifact, so that it's left out of the tagged PDF structure.
+// The page gets the copy of its resources, as they may be shared with the other pages or inherited
+// from the page tree.
+func (lp *letterheadPage) drawUnder(page *core.PdfObjectDictionary, form *core.PdfObjectStream) error {
+	resources := core.MakeDict().Merge(resolveDict(inheritedAttribute(page, "Resources")))
+	xobjects := core.MakeDict().Merge(resolveDict(resources.Get("XObject")))
+	xobjects.Set(lp.name, form)
+	resources.Set("XObject", xobjects)
+	page.Set("Resources", resources)
+
+	var dx, dy float64
+	if arr, ok := core.GetArray(core.ResolveReference(inheritedAttribute(page, "MediaBox"))); ok {
+		if box, err := model.NewPdfRectangle(*arr); err == nil {
+			dx, dy = box.Llx-lp.box.Llx, box.Ury-lp.box.Ury
+		}
+	}
+	cc := contentstream.NewContentCreator().
+		Add_BMC("Artifact").
+		Add_q().
+		Translate(dx, dy).
+		Add_Do(lp.name).
+		Add_Q().
+		Add_EMC()
+	stream, err := core.MakeStream(cc.Bytes(), core.NewFlateEncoder())
+	if err != nil {
+		return err
+	}
+
+	contents := core.MakeArray(stream)
+	switch t := core.TraceToDirectObject(page.Get("Contents")).(type) {
+	case *core.PdfObjectArray:
+		contents.Append(t.Elements()...)
+	case nil:
+	default:
+		contents.Append(page.Get("Contents"))
+	}
+	page.Set("Contents", contents)
+	return nil
+}
+
+// inheritedAttribute gets the attribute of the page, or of its nearest parent page tree node if the page
+// inherits it.
+func inheritedAttribute(page *core.PdfObjectDictionary, key core.PdfObjectName) core.PdfObject {
+	visited := map[*core.PdfObjectDictionary]struct{}{}
+	for node := page; node != nil; node = resolveDict(node.Get("Parent")) {
+		if _, ok := visited[node]; ok {
+			return nil
+		}
+		visited[node] = struct{}{}
+		if obj := node.Get(key); obj != nil {
+			return obj
+		}
+	}
+	return nil
+}
+
+// copyObject deep copies the object with the indirect objects and streams it references. The copies map
+// the copied objects to their copies, so that the objects referenced multiple times are copied once.
+func copyObject(obj core.PdfObject, copies map[core.PdfObject]core.PdfObject) core.PdfObject {
+	switch o := obj.(type) {
+	case *core.PdfIndirectObject:
+		if c, ok := copies[o]; ok {
+			return c
+		}
+		c := core.MakeIndirectObject(core.MakeNull())
+		copies[o] = c
+		c.PdfObject = copyObject(o.PdfObject, copies)
+		return c
+	case *core.PdfObjectStream:
+		if c, ok := copies[o]; ok {
+			return c
+		}
+		c := &core.PdfObjectStream{Stream: o.Stream}
+		copies[o] = c
+		c.PdfObjectDictionary, _ = copyObject(o.PdfObjectDictionary, copies).(*core.PdfObjectDictionary)
+		return c
+	case *core.PdfObjectDictionary:
+		c := core.MakeDict()
+		for _, key := range o.Keys() {
+			c.Set(key, copyObject(o.Get(key), copies))
+		}
+		return c
+	case *core.PdfObjectArray:
+		c := core.MakeArray()
+		for _, el := range o.Elements() {
+			c.Append(copyObject(el, copies))
+		}
+		return c
+	}
+	return obj
+}
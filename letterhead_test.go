@@ -0,0 +1,266 @@
+package gohtml
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/unitechio/gopdf/core"
+	"github.com/unitechio/gopdf/model"
+)
+
+// newTestLetterheadPage creates the letterhead page drawing the form with the name.
+func newTestLetterheadPage(t *testing.T, name core.PdfObjectName) *letterheadPage {
+	t.Helper()
+	form, err := core.MakeStream([]byte("0 0 500 100 re f"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	form.Set("Subtype", core.MakeName("Form"))
+	return &letterheadPage{name: name, form: form, box: model.PdfRectangle{Urx: 500, Ury: 700}}
+}
+
+// newTestPageTree creates the written objects of the document with the pages inheriting the media box and
+// the resources from the page tree, so that the resources are shared by the pages.
+func newTestPageTree(t *testing.T, count int) ([]core.PdfObject, []*core.PdfObjectDictionary, *core.PdfObjectDictionary) {
+	t.Helper()
+	image, err := core.MakeStream(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resources := core.MakeDict()
+	resources.Set("XObject", core.MakeDictMap(map[string]core.PdfObject{"Im0": image}))
+
+	tree := core.MakeDict()
+	tree.Set("Type", core.MakeName("Pages"))
+	tree.Set("MediaBox", core.MakeArrayFromFloats([]float64{0, 0, 500, 700}))
+	tree.Set("Resources", core.MakeIndirectObject(resources))
+	treeObj := core.MakeIndirectObject(tree)
+
+	catalog := core.MakeDict()
+	catalog.Set("Type", core.MakeName("Catalog"))
+	catalog.Set("Pages", treeObj)
+	objects := []core.PdfObject{core.MakeIndirectObject(catalog), treeObj}
+
+	kids := core.MakeArray()
+	var pages []*core.PdfObjectDictionary
+	for i := 0; i < count; i++ {
+		content, err := core.MakeStream([]byte("BT ET"), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		page := core.MakeDict()
+		page.Set("Type", core.MakeName("Page"))
+		page.Set("Parent", treeObj)
+		page.Set("Contents", content)
+		pageObj := core.MakeIndirectObject(page)
+		kids.Append(pageObj)
+		objects = append(objects, pageObj)
+		pages = append(pages, page)
+	}
+	tree.Set("Kids", kids)
+	tree.Set("Count", core.MakeInteger(int64(count)))
+	return objects, pages, resources
+}
+
+// pageXObjects gets the form XObjects of the page resources set on the page itself.
+func pageXObjects(t *testing.T, page *core.PdfObjectDictionary) (*core.PdfObjectDictionary, *core.PdfObjectDictionary) {
+	t.Helper()
+	resources, ok := core.GetDict(page.Get("Resources"))
+	if !ok {
+		t.Fatal("expected the resources set on the page")
+	}
+	xobjects, ok := core.GetDict(resources.Get("XObject"))
+	if !ok {
+		t.Fatal("expected the page XObject resources")
+	}
+	return resources, xobjects
+}
+
+func TestLetterheadOptimize(t *testing.T) {
+	first := newTestLetterheadPage(t, letterheadFirstName)
+	rest := newTestLetterheadPage(t, letterheadRestName)
+
+	testCases := []struct {
+		name       string
+		letterhead *Letterhead
+		expected   []core.PdfObjectName
+	}{
+		{
+			name:       "first and rest",
+			letterhead: &Letterhead{first: first, rest: rest},
+			expected:   []core.PdfObjectName{letterheadFirstName, letterheadRestName, letterheadRestName},
+		},
+		{
+			name:       "first on all pages",
+			letterhead: &Letterhead{first: first, rest: first},
+			expected:   []core.PdfObjectName{letterheadFirstName, letterheadFirstName, letterheadFirstName},
+		},
+		{
+			name:       "first page only",
+			letterhead: &Letterhead{first: first},
+			expected:   []core.PdfObjectName{letterheadFirstName, "", ""},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			objects, pages, shared := newTestPageTree(t, len(tc.expected))
+			if _, err := tc.letterhead.Optimize(objects); err != nil {
+				t.Fatal(err)
+			}
+
+			sharedXObjects, _ := core.GetDict(shared.Get("XObject"))
+			if keys := sharedXObjects.Keys(); len(keys) != 1 {
+				t.Errorf("expected the shared resources unchanged, got XObjects %v", keys)
+			}
+			forms := map[core.PdfObjectName]core.PdfObject{}
+			seen := map[*core.PdfObjectDictionary]int{}
+			for i, page := range pages {
+				name := tc.expected[i]
+				if name == "" {
+					if page.Get("Resources") != nil {
+						t.Errorf("expected page %d resources inherited, got %v", i+1, page.Get("Resources"))
+					}
+					continue
+				}
+				resources, xobjects := pageXObjects(t, page)
+				if j, ok := seen[resources]; ok {
+					t.Errorf("expected page %d resources not aliased with page %d", i+1, j+1)
+				}
+				seen[resources] = i
+				if xobjects.Get("Im0") == nil {
+					t.Errorf("expected page %d to keep the inherited XObjects, got %v", i+1, xobjects.Keys())
+				}
+
+				form := xobjects.Get(name)
+				if form == nil {
+					t.Fatalf("expected page %d letterhead %s, got %v", i+1, name, xobjects.Keys())
+				}
+				if form == first.form || form == rest.form {
+					t.Errorf("expected page %d letterhead form copied", i+1)
+				}
+				if prev, ok := forms[name]; ok && prev != form {
+					t.Errorf("expected page %d letterhead %s copied once per document", i+1, name)
+				}
+				forms[name] = form
+
+				contents, ok := core.GetArray(page.Get("Contents"))
+				if !ok || contents.Len() != 2 {
+					t.Fatalf("expected page %d letterhead stream before the content, got %v", i+1, page.Get("Contents"))
+				}
+				stream, _ := core.GetStream(contents.Get(0))
+				data, err := core.DecodeStream(stream)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Contains(data, []byte("/"+string(name)+" Do")) {
+					t.Errorf("expected page %d letterhead drawn, got %s", i+1, data)
+				}
+			}
+		})
+	}
+}
+
+func TestLetterheadOptimizeCopies(t *testing.T) {
+	l := &Letterhead{first: newTestLetterheadPage(t, letterheadFirstName)}
+	var forms []core.PdfObject
+	for i := 0; i < 2; i++ {
+		objects, pages, _ := newTestPageTree(t, 1)
+		if _, err := l.Optimize(objects); err != nil {
+			t.Fatal(err)
+		}
+		_, xobjects := pageXObjects(t, pages[0])
+		forms = append(forms, xobjects.Get(letterheadFirstName))
+	}
+	if forms[0] == forms[1] {
+		t.Error("expected the letterhead form copied for each of the written documents")
+	}
+}
+
+func TestInheritedAttribute(t *testing.T) {
+	_, pages, shared := newTestPageTree(t, 1)
+	page := pages[0]
+	if got := resolveDict(inheritedAttribute(page, "Resources")); got != shared {
+		t.Errorf("expected the page tree resources, got %v", got)
+	}
+	own := core.MakeDict()
+	page.Set("Resources", own)
+	if got := resolveDict(inheritedAttribute(page, "Resources")); got != own {
+		t.Errorf("expected the page resources, got %v", got)
+	}
+	if got := inheritedAttribute(page, "Rotate"); got != nil {
+		t.Errorf("expected no attribute, got %v", got)
+	}
+
+	// The cyclic page tree ends the lookup.
+	tree := resolveDict(page.Get("Parent"))
+	tree.Set("Parent", core.MakeIndirectObject(page))
+	if got := inheritedAttribute(page, "Rotate"); got != nil {
+		t.Errorf("expected no attribute of the cyclic page tree, got %v", got)
+	}
+}
+
+func TestCopyObject(t *testing.T) {
+	stream, err := core.MakeStream([]byte("data"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shared := core.MakeIndirectObject(core.MakeDict())
+	dict := core.MakeDict()
+	dict.Set("A", shared)
+	dict.Set("B", core.MakeArray(shared, stream))
+	stream.Set("Self", dict)
+
+	copies := map[core.PdfObject]core.PdfObject{}
+	c, ok := copyObject(dict, copies).(*core.PdfObjectDictionary)
+	if !ok || c == dict {
+		t.Fatalf("expected the dictionary copy, got %v", c)
+	}
+	a := c.Get("A")
+	arr, _ := core.GetArray(c.Get("B"))
+	if a == shared || arr.Get(0) != a {
+		t.Error("expected the indirect object referenced twice copied once")
+	}
+	s, ok := arr.Get(1).(*core.PdfObjectStream)
+	if !ok || s == stream || string(s.Stream) != "data" {
+		t.Errorf("expected the stream copy, got %v", arr.Get(1))
+	}
+	if s.Get("Self") == dict {
+		t.Error("expected the stream dictionary copied")
+	}
+}
+
+func TestDocumentLetterhead(t *testing.T) {
+	ctx := context.Background()
+	first := newTestDocument(t, &fakeConverter{data: newTestPages(t, testPage{500, 700, 100})})
+	rest := newTestDocument(t, &fakeConverter{data: newTestPages(t, testPage{500, 700, 50})})
+	l, err := NewLetterhead(ctx, first, LetterheadOptions{Rest: rest})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := newTestDocument(t, &fakeConverter{data: newTestPages(t, testPage{500, 700, 600}, testPage{500, 700, 300})})
+	d.SetLetterhead(l)
+	reader := readTestPDF(t, func(w *bytes.Buffer) error { return d.Write(w) })
+
+	if len(reader.PageList) != 2 {
+		t.Fatalf("expected 2 pages, got %d", len(reader.PageList))
+	}
+	for i, expected := range []core.PdfObjectName{letterheadFirstName, letterheadRestName} {
+		p := reader.PageList[i]
+		for _, name := range []core.PdfObjectName{letterheadFirstName, letterheadRestName} {
+			_, typ := p.Resources.GetXObjectByName(name)
+			if got := typ == model.XObjectTypeForm; got != (name == expected) {
+				t.Errorf("expected page %d letterhead %s %t, got %t", i+1, name, name == expected, got)
+			}
+		}
+		content, err := p.GetAllContentStreams()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(strings.TrimSpace(content), "/Artifact BMC") ||
+			!strings.Contains(content, "/"+string(expected)+" Do") {
+			t.Errorf("expected page %d letterhead drawn under the content, got %s", i+1, content)
+		}
+	}
+}
@@ -0,0 +1,308 @@
+package gohtml
+
+import (
+	"math"
+
+	"github.com/unitechio/gopdf/core"
+	"github.com/unitechio/gopdf/creator"
+	"github.com/unitechio/gopdf/model"
+)
+
+// renderedLink is the URI or GoTo link annotation of the rendered page.
+type renderedLink struct {
+	rect model.PdfRectangle
+
+	// action is the URI action of the external link, nil for the internal link.
+	action core.PdfObject
+
+	// target is the destination of the internal link.
+	target linkTarget
+}
+
+// linkTarget is the position on the rendered page, measured from the bottom left corner of the page.
+type linkTarget struct {
+	page int
+	x, y float64
+}
+
+// renderedLinks gets the URI and GoTo link annotations of the rendered pages, indexed by the page.
+// The links with the other actions or the destinations out of the document are dropped.
+func renderedLinks(reader *model.PdfReader) ([][]renderedLink, error) {
+	links := make([][]renderedLink, len(reader.PageList))
+	for i, p := range reader.PageList {
+		annots, err := p.GetAnnotations()
+		if err != nil {
+			return nil, err
+		}
+		for _, annot := range annots {
+			link, ok := annot.GetContext().(*model.PdfAnnotationLink)
+			if !ok {
+				continue
+			}
+			arr, ok := core.GetArray(annot.Rect)
+			if !ok {
+				continue
+			}
+			rect, err := model.NewPdfRectangle(*arr)
+			if err != nil {
+				continue
+			}
+			l, ok := resolveLink(reader, link)
+			if !ok {
+				continue
+			}
+			l.rect = *rect
+			links[i] = append(links[i], l)
+		}
+	}
+	return links, nil
+}
+
+// resolveLink gets the action of the URI link or the target of the GoTo link.
+func resolveLink(reader *model.PdfReader, link *model.PdfAnnotationLink) (renderedLink, bool) {
+	dest := link.Dest
+	if action, ok := core.GetDict(link.A); ok {
+		name, _ := core.GetNameVal(action.Get("S"))
+		switch name {
+		case "URI":
+			return renderedLink{action: link.A}, true
+		case "GoTo":
+			dest = action.Get("D")
+		default:
+			return renderedLink{}, false
+		}
+	}
+	target, ok := resolveDest(reader, dest)
+	return renderedLink{target: target}, ok
+}
+
+// resolveDest gets the target of the explicit or the named destination. The named destinations are looked up
+// in the catalog Dests dictionary and the Dests name tree.
+func resolveDest(reader *model.PdfReader, dest core.PdfObject) (linkTarget, bool) {
+	switch t := core.TraceToDirectObject(dest).(type) {
+	case *core.PdfObjectName:
+		dests, err := reader.GetNamedDestinations()
+		if err != nil {
+			return linkTarget{}, false
+		}
+		if dict, ok := core.GetDict(dests); ok {
+			return resolveDest(reader, namedDest(dict.Get(*t)))
+		}
+	case *core.PdfObjectString:
+		names, err := reader.GetNameDictionary()
+		if err != nil {
+			return linkTarget{}, false
+		}
+		if dict, ok := core.GetDict(names); ok {
+			return resolveDest(reader, namedDest(lookupNameTree(dict.Get("Dests"), t.Str())))
+		}
+	case *core.PdfObjectArray:
+		return explicitDest(reader, t)
+	}
+	return linkTarget{}, false
+}
+
+// namedDest gets the destination array of the named destination, which is either the array or
+// the dictionary with the D entry.
+func namedDest(obj core.PdfObject) core.PdfObject {
+	if dict, ok := core.GetDict(obj); ok {
+		return dict.Get("D")
+	}
+	return obj
+}
+
+// lookupNameTree gets the value of the name in the name tree.
+func lookupNameTree(node core.PdfObject, name string) core.PdfObject {
+	dict, ok := core.GetDict(node)
+	if !ok {
+		return nil
+	}
+	if names, ok := core.GetArray(dict.Get("Names")); ok {
+		for i := 0; i+1 < names.Len(); i += 2 {
+			if key, ok := core.GetString(names.Get(i)); ok && key.Str() == name {
+				return names.Get(i + 1)
+			}
+		}
+	}
+	if kids, ok := core.GetArray(dict.Get("Kids")); ok {
+		for _, kid := range kids.Elements() {
+			if value := lookupNameTree(kid, name); value != nil {
+				return value
+			}
+		}
+	}
+	return nil
+}
+
+// explicitDest gets the target of the destination array. The destinations that don't define the position,
+// i.e. /Fit, target the top left corner of the page.
+func explicitDest(reader *model.PdfReader, dest *core.PdfObjectArray) (linkTarget, bool) {
+	if dest.Len() < 2 {
+		return linkTarget{}, false
+	}
+	target := linkTarget{page: -1}
+	switch t := dest.Get(0).(type) {
+	case *core.PdfIndirectObject:
+		if _, num, err := reader.PageFromIndirectObject(t); err == nil {
+			target.page = num - 1
+		}
+	case *core.PdfObjectInteger:
+		target.page = int(*t)
+	}
+	if target.page < 0 || target.page >= len(reader.PageList) {
+		return linkTarget{}, false
+	}
+	box, err := reader.PageList[target.page].GetMediaBox()
+	if err != nil {
+		return linkTarget{}, false
+	}
+	target.x, target.y = box.Llx, box.Ury
+
+	// The left and top operands of the destination, null operands keep the defaults.
+	operand := func(i int, v *float64) {
+		if f, err := core.GetNumberAsFloat(dest.Get(i)); err == nil {
+			*v = f
+		}
+	}
+	kind, _ := core.GetNameVal(dest.Get(1))
+	switch kind {
+	case "XYZ":
+		operand(2, &target.x)
+		operand(3, &target.y)
+	case "FitH", "FitBH":
+		operand(2, &target.y)
+	case "FitV", "FitBV":
+		operand(2, &target.x)
+	case "FitR":
+		operand(2, &target.x)
+		operand(5, &target.y)
+	}
+	return target, true
+}
+
+// pageLinks adds the link annotations of the rendered pages to their blocks. The internal link destinations
+// are set by the place, once all of the pages are placed.
+type pageLinks struct {
+	rendered [][]renderedLink
+	internal bool
+	dests    []placedDest
+}
+
+// placedDest is the destination array of the internal link added to the block.
+type placedDest struct {
+	dest   *core.PdfObjectArray
+	target linkTarget
+}
+
+// newPageLinks gets the links of the rendered document. Unless the internal is set, only the URI links
+// are added to the blocks, i.e. when the position of the target page is not known.
+func newPageLinks(reader *model.PdfReader, internal bool) (*pageLinks, error) {
+	rendered, err := renderedLinks(reader)
+	if err != nil {
+		return nil, err
+	}
+	return &pageLinks{rendered: rendered, internal: internal}, nil
+}
+
+// add adds the links of the rendered page to the block drawn from the page box. The links are clipped
+// to the box and the links out of it are dropped.
+func (l *pageLinks) add(block *creator.Block, page int, box model.PdfRectangle) {
+	for _, link := range l.rendered[page] {
+		if link.action == nil && !l.internal {
+			continue
+		}
+		rect := model.PdfRectangle{
+			Llx: math.Max(math.Min(link.rect.Llx, link.rect.Urx), box.Llx),
+			Lly: math.Max(math.Min(link.rect.Lly, link.rect.Ury), box.Lly),
+			Urx: math.Min(math.Max(link.rect.Llx, link.rect.Urx), box.Urx),
+			Ury: math.Min(math.Max(link.rect.Lly, link.rect.Ury), box.Ury),
+		}
+		if rect.Width() <= 0 || rect.Height() <= 0 {
+			continue
+		}
+		// The block is drawn from the box moved to the origin.
+		rect.Llx, rect.Urx = rect.Llx-box.Llx, rect.Urx-box.Llx
+		rect.Lly, rect.Ury = rect.Lly-box.Lly, rect.Ury-box.Lly
+
+		annot := model.NewPdfAnnotationLink()
+		annot.Rect = rect.ToPdfObject()
+		border := model.NewBorderStyle()
+		border.SetBorderWidth(0)
+		annot.BS = border.ToPdfObject()
+		if link.action != nil {
+			annot.A = link.action
+		} else {
+			dest := core.MakeArray()
+			annot.Dest = dest
+			l.dests = append(l.dests, placedDest{dest: dest, target: link.target})
+		}
+		block.AddAnnotation(annot.PdfAnnotation)
+	}
+}
+
+// place sets the internal link destinations to the positions of the target pages drawn at the placements,
+// the same way as the creator sets the destinations of its internal links. The targets out of the drawn
+// page boxes are moved to their nearest edge.
+func (l *pageLinks) place(placements []pagePlacement, pageHeight float64) {
+	for _, d := range l.dests {
+		p, ok := placementAt(placements, d.target.page, d.target.y)
+		if !ok {
+			continue
+		}
+		x := math.Min(math.Max(d.target.x, p.box.Llx), p.box.Urx)
+		y := math.Min(math.Max(d.target.y, p.box.Lly), p.box.Ury)
+		d.dest.Append(
+			core.MakeInteger(p.page),
+			core.MakeName("XYZ"),
+			core.MakeFloat(p.x+x-p.box.Llx),
+			core.MakeFloat(pageHeight-p.y-p.box.Ury+y),
+			core.MakeFloat(0),
+		)
+	}
+}
+
+// resolveNamedDests replaces the named destinations of the rendered page links with the explicit ones,
+// as the names of the rendered document catalog are not carried to the output document.
+func resolveNamedDests(reader *model.PdfReader) error {
+	for _, p := range reader.PageList {
+		annots, err := p.GetAnnotations()
+		if err != nil {
+			return err
+		}
+		for _, annot := range annots {
+			link, ok := annot.GetContext().(*model.PdfAnnotationLink)
+			if !ok || !isNamedDest(link) {
+				continue
+			}
+			l, ok := resolveLink(reader, link)
+			if !ok {
+				continue
+			}
+			link.A = nil
+			link.Dest = core.MakeArray(
+				reader.PageList[l.target.page].GetPageAsIndirectObject(),
+				core.MakeName("XYZ"),
+				core.MakeFloat(l.target.x),
+				core.MakeFloat(l.target.y),
+				core.MakeFloat(0),
+			)
+		}
+	}
+	return nil
+}
+
+// isNamedDest checks if the link or its GoTo action targets the named destination.
+func isNamedDest(link *model.PdfAnnotationLink) bool {
+	dest := link.Dest
+	if action, ok := core.GetDict(link.A); ok {
+		if name, _ := core.GetNameVal(action.Get("S")); name != "GoTo" {
+			return false
+		}
+		dest = action.Get("D")
+	}
+	switch core.TraceToDirectObject(dest).(type) {
+	case *core.PdfObjectName, *core.PdfObjectString:
+		return true
+	}
+	return false
+}
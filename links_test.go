@@ -0,0 +1,141 @@
+package gohtml
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/unitechio/gopdf/core"
+	"github.com/unitechio/gopdf/creator"
+	"github.com/unitechio/gopdf/model"
+)
+
+func TestExplicitDest(t *testing.T) {
+	c := creator.New()
+	c.SetPageSize(creator.PageSize{600, 800})
+	c.NewPage()
+	c.NewPage()
+	var buf bytes.Buffer
+	if err := c.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	reader, err := model.NewPdfReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := reader.PageList[1].GetPageAsIndirectObject()
+
+	testCases := []struct {
+		name     string
+		dest     *core.PdfObjectArray
+		expected linkTarget
+		ok       bool
+	}{
+		{
+			name:     "integer page",
+			dest:     core.MakeArray(core.MakeInteger(1), core.MakeName("XYZ"), core.MakeFloat(10), core.MakeFloat(700), core.MakeFloat(0)),
+			expected: linkTarget{page: 1, x: 10, y: 700},
+			ok:       true,
+		},
+		{
+			name:     "page object",
+			dest:     core.MakeArray(page, core.MakeName("XYZ"), core.MakeFloat(10), core.MakeFloat(700), core.MakeNull()),
+			expected: linkTarget{page: 1, x: 10, y: 700},
+			ok:       true,
+		},
+		{
+			name:     "null operands",
+			dest:     core.MakeArray(core.MakeInteger(0), core.MakeName("XYZ"), core.MakeNull(), core.MakeNull(), core.MakeNull()),
+			expected: linkTarget{page: 0, x: 0, y: 800},
+			ok:       true,
+		},
+		{
+			name:     "fit",
+			dest:     core.MakeArray(core.MakeInteger(0), core.MakeName("Fit")),
+			expected: linkTarget{page: 0, x: 0, y: 800},
+			ok:       true,
+		},
+		{
+			name:     "fit horizontally",
+			dest:     core.MakeArray(core.MakeInteger(0), core.MakeName("FitH"), core.MakeFloat(500)),
+			expected: linkTarget{page: 0, x: 0, y: 500},
+			ok:       true,
+		},
+		{
+			name:     "fit vertically",
+			dest:     core.MakeArray(core.MakeInteger(0), core.MakeName("FitV"), core.MakeFloat(30)),
+			expected: linkTarget{page: 0, x: 30, y: 800},
+			ok:       true,
+		},
+		{
+			name: "fit rectangle",
+			dest: core.MakeArray(core.MakeInteger(0), core.MakeName("FitR"),
+				core.MakeFloat(10), core.MakeFloat(20), core.MakeFloat(30), core.MakeFloat(40)),
+			expected: linkTarget{page: 0, x: 10, y: 40},
+			ok:       true,
+		},
+		{
+			name: "page out of document",
+			dest: core.MakeArray(core.MakeInteger(2), core.MakeName("Fit")),
+		},
+		{
+			name: "no kind",
+			dest: core.MakeArray(core.MakeInteger(0)),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			target, ok := explicitDest(reader, tc.dest)
+			if ok != tc.ok {
+				t.Fatalf("expected resolved %t, got %t", tc.ok, ok)
+			}
+			if ok && target != tc.expected {
+				t.Errorf("expected target %+v, got %+v", tc.expected, target)
+			}
+		})
+	}
+}
+
+func TestPageLinksPlace(t *testing.T) {
+	const pageHeight = 800
+
+	testCases := []struct {
+		name   string
+		target linkTarget
+		// page, x and y are the expected XYZ destination on the creator page, measured from its bottom left corner.
+		page int64
+		x, y float64
+		ok   bool
+	}{
+		{name: "first part", target: linkTarget{page: 0, x: 10, y: 700}, page: 2, x: 60, y: 600, ok: true},
+		{name: "second part", target: linkTarget{page: 0, x: 0, y: 300}, page: 3, x: 50, y: 650, ok: true},
+		{name: "page top", target: linkTarget{page: 0, x: 0, y: 800}, page: 2, x: 50, y: 700, ok: true},
+		{name: "above the box", target: linkTarget{page: 0, x: 0, y: 900}, page: 2, x: 50, y: 700, ok: true},
+		{name: "content box offset", target: linkTarget{page: 1, x: 30, y: 700}, page: 3, x: 60, y: 250, ok: true},
+		{name: "left of the box", target: linkTarget{page: 1, x: 0, y: 800}, page: 3, x: 50, y: 350, ok: true},
+		{name: "page not drawn", target: linkTarget{page: 2, x: 0, y: 800}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dest := core.MakeArray()
+			links := &pageLinks{dests: []placedDest{{dest: dest, target: tc.target}}}
+			links.place(outlinePlacements, pageHeight)
+			if !tc.ok {
+				if dest.Len() != 0 {
+					t.Errorf("expected no destination, got %v", dest)
+				}
+				return
+			}
+			if dest.Len() != 5 {
+				t.Fatalf("expected the XYZ destination, got %v", dest)
+			}
+			page, _ := core.GetIntVal(dest.Get(0))
+			kind, _ := core.GetNameVal(dest.Get(1))
+			x, _ := core.GetNumberAsFloat(dest.Get(2))
+			y, _ := core.GetNumberAsFloat(dest.Get(3))
+			if int64(page) != tc.page || kind != "XYZ" || math.Abs(x-tc.x) > 1e-9 || math.Abs(y-tc.y) > 1e-9 {
+				t.Errorf("expected [%d /XYZ %g %g 0], got %v", tc.page, tc.x, tc.y, dest)
+			}
+		})
+	}
+}
@@ -0,0 +1,284 @@
+package gohtml
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"reflect"
+	"slices"
+
+	"github.com/unitechio/gopdf/common"
+	"github.com/unitechio/gopdf/core"
+	"github.com/unitechio/gopdf/creator"
+	"github.com/unitechio/gopdf/model"
+)
+
+// ErrOutlineJavaScript is returned when the outline selectors are set with the JavaScript disabled.
+var ErrOutlineJavaScript = errors.New("outline selectors require JavaScript enabled")
+
+// SetOutline enables the PDF outline (bookmarks) generated from the HTML headings. The selectors define
+// the elements of the subsequent outline levels, i.e. SetOutline("h1", ".section-title") makes the h1 elements
+// the top level entries and the .section-title elements their children. With no selectors the h1-h6 headings
+// are used. The selectors are applied by the page script, so that they can't be used with DisableJavaScript.
+// Drawn in a creator.Chapter the outline entries are added under the chapter outline item, which is written
+// by the creator along with the other chapters. Otherwise, i.e. outside of the chapters or in a creator.Division,
+// the document drawn by the creator has no outline.
+func (d *Document) SetOutline(selectors ...string) {
+	d.outline = true
+	d.outlineSel = selectors
+}
+
+// outlineScript gets the script making the elements matching the outline selectors the only page headings,
+// with the heading level of the selector position.
+func outlineScript(selectors []string) (string, error) {
+	data, err := json.Marshal(selectors)
+	if err != nil {
+		return "", err
+	}
+	return `(function (levels) {
+	document.querySelectorAll('h1,h2,h3,h4,h5,h6,[role="heading"]').forEach(function (el) {
+		el.setAttribute('role', 'none');
+	});
+	levels.forEach(function (selector, i) {
+		document.querySelectorAll(selector).forEach(function (el) {
+			el.setAttribute('role', 'heading');
+			el.setAttribute('aria-level', String(i + 1));
+		});
+	});
+})(` + string(data) + `);`, nil
+}
+
+// renderedOutline gets the top level outline items of the rendered PDF.
+func renderedOutline(reader *model.PdfReader) ([]*model.OutlineItem, error) {
+	outline, err := reader.GetOutlines()
+	if err != nil || outline == nil {
+		return nil, err
+	}
+	return outline.Entries, nil
+}
+
+// pagePlacement is the position of the rendered page box drawn on the creator page. The rendered page
+// taller than the creator page is drawn in multiple boxes.
+type pagePlacement struct {
+	// source is the zero based index of the rendered page.
+	source int
+	// page is the zero based index of the creator page.
+	page int64
+	// x and y are the top left position of the drawn page, measured from the top left corner of the creator page.
+	x, y float64
+	box  model.PdfRectangle
+}
+
+// placementAt gets the placement of the rendered page box that is the nearest to the y position on the page.
+func placementAt(placements []pagePlacement, source int, y float64) (pagePlacement, bool) {
+	var (
+		found    pagePlacement
+		ok       bool
+		distance float64
+	)
+	for _, p := range placements {
+		if p.source != source {
+			continue
+		}
+		dist := max(p.box.Lly-y, y-p.box.Ury, 0)
+		if !ok || dist < distance {
+			found, ok, distance = p, true, dist
+		}
+	}
+	return found, ok
+}
+
+// placeOutline copies the outline items with their destinations moved to the drawn pages, as expected by
+// the creator outline: the zero based page index and the position measured from the top left corner
+// of the page. The destinations that don't define the position, i.e. /Fit, target the top left corner
+// of the page, the same way as the link destinations. The destinations on the pages that weren't drawn
+// are dropped with their children.
+func placeOutline(items []*model.OutlineItem, placements []pagePlacement) []*model.OutlineItem {
+	var placed []*model.OutlineItem
+	for _, item := range items {
+		x, y := item.Dest.X, item.Dest.Y
+		switch item.Dest.Mode {
+		case "XYZ":
+		case "FitH", "FitBH":
+			x = math.Inf(-1)
+		case "FitV", "FitBV":
+			y = math.Inf(1)
+		default:
+			x, y = math.Inf(-1), math.Inf(1)
+		}
+		// The page top is found in the first placement of the page.
+		p, ok := placementAt(placements, int(item.Dest.Page), y)
+		if !ok {
+			continue
+		}
+		x = math.Min(math.Max(x, p.box.Llx), p.box.Urx)
+		y = math.Min(math.Max(y, p.box.Lly), p.box.Ury)
+		dest := model.NewOutlineDest(p.page, p.x+x-p.box.Llx, p.y+p.box.Ury-y)
+		c := model.NewOutlineItem(item.Title, dest)
+		for _, child := range placeOutline(item.Entries, placements) {
+			c.Add(child)
+		}
+		placed = append(placed, c)
+	}
+	return placed
+}
+
+// addOutline adds the outline of the rendered document drawn at the placements under the outline item
+// of the enclosing chapter. The entries added by the previous layout of the document are replaced, so that
+// the document drawn again doesn't duplicate them.
+func (d *Document) addOutline(reader *model.PdfReader, placements []pagePlacement) error {
+	if d.chapter == nil {
+		common.Log.Debug("Document outline is skipped outside of a chapter")
+		return nil
+	}
+	parent, err := chapterOutline(d.chapter)
+	if err != nil {
+		return err
+	}
+	items, err := renderedOutline(reader)
+	if err != nil {
+		return err
+	}
+	parent.Entries = slices.DeleteFunc(parent.Entries, func(item *model.OutlineItem) bool {
+		return slices.Contains(d.outlined, item)
+	})
+	d.outlined = placeOutline(items, placements)
+	for _, item := range d.outlined {
+		parent.Add(item)
+	}
+	return nil
+}
+
+// errChapterOutline is returned when the outline item of the creator.Chapter can't be found.
+var errChapterOutline = errors.New("creator chapter outline item not found")
+
+// chapterOutlineField is the index of the only creator.Chapter field of the *model.OutlineItem type,
+// or -1 if there is no such field or there are more of them.
+var chapterOutlineField = func() int {
+	field := -1
+	chapterType := reflect.TypeOf(creator.Chapter{})
+	itemType := reflect.TypeOf((*model.OutlineItem)(nil))
+	for i := 0; i < chapterType.NumField(); i++ {
+		if chapterType.Field(i).Type != itemType {
+			continue
+		}
+		if field != -1 {
+			return -1
+		}
+		field = i
+	}
+	return field
+}()
+
+// chapterOutline gets the outline item of the drawn chapter. The creator doesn't expose it, so that it's read
+// from the only chapter field of its type. The function fails if the chapter layout is not the expected one.
+func chapterOutline(ch *creator.Chapter) (*model.OutlineItem, error) {
+	if chapterOutlineField == -1 {
+		return nil, errChapterOutline
+	}
+	f := reflect.ValueOf(ch).Elem().Field(chapterOutlineField)
+	if f.IsNil() {
+		return nil, errChapterOutline
+	}
+	return (*model.OutlineItem)(f.UnsafePointer()), nil
+}
+
+// outlineTree is the optimizer writing the outline of the document pages into the written document catalog.
+type outlineTree struct {
+	items []*model.OutlineItem
+}
+
+// Optimize implements the model.Optimizer interface.
+func (t *outlineTree) Optimize(objects []core.PdfObject) ([]core.PdfObject, error) {
+	catalog := findCatalog(objects)
+	if catalog == nil || len(t.items) == 0 {
+		return objects, nil
+	}
+
+	pages := writtenPages(catalog)
+	outline := model.NewOutline()
+	for _, item := range t.items {
+		if resolved, ok := resolveOutlinePages(item, pages); ok {
+			outline.Add(resolved)
+		}
+	}
+
+	root := outline.ToPdfOutline().ToPdfObject()
+	catalog.Set("Outlines", root)
+	catalog.Set("PageMode", core.MakeName("UseOutlines"))
+	return appendReferenced(objects, root), nil
+}
+
+// resolveOutlinePages copies the item and its children with the destination page objects set. The children
+// with the destination out of the written pages are dropped. The items are copied, so that the outline is
+// resolved again by the next write.
+func resolveOutlinePages(item *model.OutlineItem, pages []core.PdfObject) (*model.OutlineItem, bool) {
+	if item.Dest.Page < 0 || int(item.Dest.Page) >= len(pages) {
+		return nil, false
+	}
+	dest := item.Dest
+	dest.PageObj, _ = core.GetIndirect(pages[item.Dest.Page])
+	c := model.NewOutlineItem(item.Title, dest)
+	for _, child := range item.Entries {
+		if resolved, ok := resolveOutlinePages(child, pages); ok {
+			c.Add(resolved)
+		}
+	}
+	return c, true
+}
+
+// appendReferenced appends the indirect objects referenced by the obj that are missing in the objects.
+// The page objects are not followed.
+func appendReferenced(objects []core.PdfObject, obj core.PdfObject) []core.PdfObject {
+	written := make(map[core.PdfObject]struct{}, len(objects))
+	for _, o := range objects {
+		written[o] = struct{}{}
+	}
+
+	var walk func(o core.PdfObject)
+	walk = func(o core.PdfObject) {
+		switch t := o.(type) {
+		case *core.PdfIndirectObject:
+			if _, ok := written[t]; ok {
+				return
+			}
+			if dict, ok := t.PdfObject.(*core.PdfObjectDictionary); ok && isPageObject(dict) {
+				return
+			}
+			written[t] = struct{}{}
+			objects = append(objects, t)
+			walk(t.PdfObject)
+		case *core.PdfObjectStream:
+			if _, ok := written[t]; ok {
+				return
+			}
+			written[t] = struct{}{}
+			objects = append(objects, t)
+			walk(t.PdfObjectDictionary)
+		case *core.PdfObjectDictionary:
+			for _, key := range t.Keys() {
+				walk(t.Get(key))
+			}
+		case *core.PdfObjectArray:
+			for _, el := range t.Elements() {
+				walk(el)
+			}
+		}
+	}
+	walk(obj)
+	return objects
+}
+
+// optimizers chains the PDF writer optimizers.
+type optimizers []model.Optimizer
+
+// Optimize implements the model.Optimizer interface.
+func (o optimizers) Optimize(objects []core.PdfObject) ([]core.PdfObject, error) {
+	var err error
+	for _, opt := range o {
+		if objects, err = opt.Optimize(objects); err != nil {
+			return nil, err
+		}
+	}
+	return objects, nil
+}
@@ -0,0 +1,180 @@
+package gohtml
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/unitechio/gopdf/core"
+	"github.com/unitechio/gopdf/creator"
+	"github.com/unitechio/gopdf/model"
+)
+
+// The rendered page 0 is split across the creator pages 2 and 3, the rendered page 1 is drawn on the page 3.
+var outlinePlacements = []pagePlacement{
+	{source: 0, page: 2, x: 50, y: 100, box: model.PdfRectangle{Llx: 0, Lly: 400, Urx: 600, Ury: 800}},
+	{source: 0, page: 3, x: 50, y: 50, box: model.PdfRectangle{Llx: 0, Lly: 0, Urx: 600, Ury: 400}},
+	{source: 1, page: 3, x: 50, y: 450, box: model.PdfRectangle{Llx: 20, Lly: 600, Urx: 620, Ury: 800}},
+}
+
+func TestPlacementAt(t *testing.T) {
+	testCases := []struct {
+		name     string
+		source   int
+		y        float64
+		expected int64
+		ok       bool
+	}{
+		{name: "first part", source: 0, y: 700, expected: 2, ok: true},
+		{name: "second part", source: 0, y: 100, expected: 3, ok: true},
+		{name: "part boundary", source: 0, y: 400, expected: 2, ok: true},
+		{name: "above the parts", source: 0, y: 900, expected: 2, ok: true},
+		{name: "below the parts", source: 0, y: -10, expected: 3, ok: true},
+		{name: "trimmed part", source: 1, y: 100, expected: 3, ok: true},
+		{name: "page not drawn", source: 2, y: 100},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, ok := placementAt(outlinePlacements, tc.source, tc.y)
+			if ok != tc.ok {
+				t.Fatalf("expected found %t, got %t", tc.ok, ok)
+			}
+			if ok && p.page != tc.expected {
+				t.Errorf("expected page %d, got %d", tc.expected, p.page)
+			}
+		})
+	}
+}
+
+func TestPlaceOutline(t *testing.T) {
+	testCases := []struct {
+		name string
+		dest model.OutlineDest
+		// page, x and y are the expected creator page and the position measured from its top left corner.
+		page int64
+		x, y float64
+		ok   bool
+	}{
+		{name: "first part", dest: model.NewOutlineDest(0, 10, 780), page: 2, x: 60, y: 120, ok: true},
+		{name: "second part", dest: model.NewOutlineDest(0, 0, 300), page: 3, x: 50, y: 150, ok: true},
+		{name: "content box offset", dest: model.NewOutlineDest(1, 30, 700), page: 3, x: 60, y: 550, ok: true},
+		{name: "page not drawn", dest: model.NewOutlineDest(2, 0, 700)},
+		{name: "fit", dest: model.OutlineDest{Page: 0, Mode: "Fit"}, page: 2, x: 50, y: 100, ok: true},
+		{name: "fit horizontally", dest: model.OutlineDest{Page: 0, Mode: "FitH", X: 30, Y: 300}, page: 3, x: 50, y: 150, ok: true},
+		{name: "fit vertically", dest: model.OutlineDest{Page: 1, Mode: "FitV", X: 30}, page: 3, x: 60, y: 450, ok: true},
+		{name: "out of the box", dest: model.NewOutlineDest(1, 700, 900), page: 3, x: 650, y: 450, ok: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			item := model.NewOutlineItem("Heading", tc.dest)
+			item.Add(model.NewOutlineItem("Child", tc.dest))
+			placed := placeOutline([]*model.OutlineItem{item}, outlinePlacements)
+			if !tc.ok {
+				if len(placed) != 0 {
+					t.Errorf("expected the item dropped, got %d items", len(placed))
+				}
+				return
+			}
+			if len(placed) != 1 || len(placed[0].Entries) != 1 {
+				t.Fatalf("expected the item with its child placed, got %d items", len(placed))
+			}
+			for _, p := range []*model.OutlineItem{placed[0], placed[0].Entries[0]} {
+				if p.Dest.Page != tc.page || math.Abs(p.Dest.X-tc.x) > 1e-9 || math.Abs(p.Dest.Y-tc.y) > 1e-9 {
+					t.Errorf("expected %q placed on page %d at (%g, %g), got page %d at (%g, %g)",
+						p.Title, tc.page, tc.x, tc.y, p.Dest.Page, p.Dest.X, p.Dest.Y)
+				}
+			}
+			if item.Dest != tc.dest {
+				t.Errorf("expected the rendered item unchanged, got %+v", item.Dest)
+			}
+		})
+	}
+}
+
+func TestChapterOutline(t *testing.T) {
+	// The outline item is read from the chapter field, that is to be the only one of its type.
+	if chapterOutlineField == -1 {
+		t.Fatal("expected a single outline item field of the creator.Chapter")
+	}
+
+	c := creator.New()
+	ch := c.NewChapter("Chapter")
+	if _, err := chapterOutline(ch); err == nil {
+		t.Error("expected no outline item before the chapter is drawn")
+	}
+	if err := c.Draw(ch); err != nil {
+		t.Fatal(err)
+	}
+	item, err := chapterOutline(ch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.Title != "1. Chapter" {
+		t.Errorf("expected the chapter outline item, got %q", item.Title)
+	}
+}
+
+func TestResolveOutlinePages(t *testing.T) {
+	pages := []core.PdfObject{core.MakeIndirectObject(core.MakeDict()), core.MakeIndirectObject(core.MakeDict())}
+	item := model.NewOutlineItem("Heading", model.NewOutlineDest(1, 0, 100))
+	item.Add(model.NewOutlineItem("Out of pages", model.NewOutlineDest(2, 0, 100)))
+	item.Add(model.NewOutlineItem("Child", model.NewOutlineDest(0, 0, 100)))
+
+	for i := 0; i < 2; i++ {
+		resolved, ok := resolveOutlinePages(item, pages)
+		if !ok {
+			t.Fatal("expected the item resolved")
+		}
+		if resolved.Dest.PageObj != pages[1] {
+			t.Errorf("expected the page object set, got %v", resolved.Dest.PageObj)
+		}
+		if len(resolved.Entries) != 1 || resolved.Entries[0].Dest.PageObj != pages[0] {
+			t.Errorf("expected the child on the written pages resolved, got %d entries", len(resolved.Entries))
+		}
+	}
+	if len(item.Entries) != 2 || item.Dest.PageObj != nil {
+		t.Errorf("expected the written item unchanged, got %d entries", len(item.Entries))
+	}
+	if _, ok := resolveOutlinePages(model.NewOutlineItem("Heading", model.NewOutlineDest(-1, 0, 0)), pages); ok {
+		t.Error("expected the item out of the pages dropped")
+	}
+}
+
+func TestChapterDocumentOutline(t *testing.T) {
+	w := newTestWriter(t, testPage{500, 700, 600})
+	outline := model.NewOutline()
+	outline.Add(model.NewOutlineItem("Heading", model.NewOutlineDest(0, 0, 650)))
+	w.AddOutlineTree(&outline.ToPdfOutline().PdfOutlineTreeNode)
+	d := newTestDocument(t, &fakeConverter{data: writeTestPDF(t, w)})
+	d.SetOutline()
+
+	c := creator.New()
+	ch := c.NewChapter("Chapter")
+	if err := ch.Add(d); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Draw(ch); err != nil {
+		t.Fatal(err)
+	}
+	// The document laid out again doesn't duplicate its entries.
+	ctx := creator.DrawContext{Page: 1, X: 50, Y: 100, Width: 495, Height: 700, PageWidth: 595, PageHeight: 842}
+	if _, _, err := d.GeneratePageBlocks(ctx); err != nil {
+		t.Fatal(err)
+	}
+	reader := readTestPDF(t, func(w *bytes.Buffer) error { return c.Write(w) })
+
+	written, err := reader.GetOutlines()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(written.Entries) != 1 || written.Entries[0].Title != "1. Chapter" {
+		t.Fatalf("expected the chapter outline item, got %d items", len(written.Entries))
+	}
+	entries := written.Entries[0].Entries
+	if len(entries) != 1 || entries[0].Title != "Heading" {
+		t.Fatalf("expected the heading under the chapter item, got %d entries", len(entries))
+	}
+	if entries[0].Dest.PageObj != reader.PageList[0].GetPageAsIndirectObject() {
+		t.Errorf("expected the heading on the first page, got page %d", entries[0].Dest.Page)
+	}
+}
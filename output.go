@@ -0,0 +1,314 @@
+package gohtml
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"html"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/unitechio/gohtml/common"
+	"github.com/unitechio/gopdf/core"
+	"github.com/unitechio/gopdf/creator"
+	"github.com/unitechio/gopdf/model"
+	"github.com/unitechio/gopdf/model/xmputil"
+)
+
+// ===================== METADATA =====================
+
+// Metadata is the PDF document information written with the Document output.
+type Metadata struct {
+	// Title is the document title. If empty, the title is taken from the HTML <title> element.
+	Title    string
+	Author   string
+	Subject  string
+	Keywords []string
+
+	// Creator is the name of the application that created the original document.
+	Creator string
+
+	// Producer is the name of the application that produced the PDF. Defaults to the UniHTML with its version.
+	Producer string
+
+	// CreationDate and ModifiedDate default to the time of writing the document.
+	CreationDate time.Time
+	ModifiedDate time.Time
+
+	// XMP is the raw XMP metadata packet stored in the document catalog. If empty, the XMP metadata is
+	// generated from the document information.
+	XMP []byte
+}
+
+// SetMetadata sets the PDF document information of the output.
+func (d *Document) SetMetadata(m Metadata) { d.metadata = &m }
+
+// resolvedMetadata gets the metadata with the defaults filled in.
+func (d *Document) resolvedMetadata(now time.Time) Metadata {
+	var m Metadata
+	if d.metadata != nil {
+		m = *d.metadata
+	}
+	if m.Title == "" {
+		m.Title = d.htmlTitle()
+	}
+	if m.Producer == "" {
+		m.Producer = "UniHTML " + common.Version
+	}
+	if m.CreationDate.IsZero() {
+		m.CreationDate = now
+	}
+	if m.ModifiedDate.IsZero() {
+		m.ModifiedDate = m.CreationDate
+	}
+	return m
+}
+
+// pdfInfo gets the PDF document information dictionary with the text strings made by the makeString.
+func (m Metadata) pdfInfo(makeString func(string) *core.PdfObjectString) (*model.PdfInfo, error) {
+	info := &model.PdfInfo{}
+	for _, field := range []struct {
+		dst   **core.PdfObjectString
+		value string
+	}{
+		{&info.Title, m.Title},
+		{&info.Author, m.Author},
+		{&info.Subject, m.Subject},
+		{&info.Keywords, strings.Join(m.Keywords, ", ")},
+		{&info.Creator, m.Creator},
+		{&info.Producer, m.Producer},
+	} {
+		if field.value != "" {
+			*field.dst = makeString(field.value)
+		}
+	}
+
+	created, err := model.NewPdfDateFromTime(m.CreationDate)
+	if err != nil {
+		return nil, err
+	}
+	modified, err := model.NewPdfDateFromTime(m.ModifiedDate)
+	if err != nil {
+		return nil, err
+	}
+	info.CreationDate, info.ModifiedDate = &created, &modified
+	return info, nil
+}
+
+// xmpMetadata gets the XMP metadata stream for the document catalog.
+func (m Metadata) xmpMetadata() (*core.PdfObjectStream, error) {
+	data := m.XMP
+	if len(data) == 0 {
+		// The XMP packet is UTF-8 encoded, so that the information strings are passed as is.
+		info, err := m.pdfInfo(core.MakeString)
+		if err != nil {
+			return nil, err
+		}
+		doc := xmputil.NewDocument()
+		if err = doc.SetPdfInfo(&xmputil.PdfInfoOptions{InfoDict: info.ToPdfObject(), Overwrite: true}); err != nil {
+			return nil, err
+		}
+		if data, err = doc.MarshalIndent("", "  "); err != nil {
+			return nil, err
+		}
+	}
+	stream, err := core.MakeStream(data, nil)
+	if err != nil {
+		return nil, err
+	}
+	stream.Set("Type", core.MakeName("Metadata"))
+	stream.Set("Subtype", core.MakeName("XML"))
+	return stream, nil
+}
+
+// makeTextString makes the text string, UTF-16 encoded only if the s isn't ASCII.
+func makeTextString(s string) *core.PdfObjectString {
+	if isASCII(s) {
+		return core.MakeString(s)
+	}
+	return core.MakeEncodedString(s, true)
+}
+
+// makePDFATextString makes the text string of the PDF/A document information, that is copied as is into
+// the XMP metadata by the PDF/A profile. The non-ASCII strings are left out, as the XMP metadata has them already.
+func makePDFATextString(s string) *core.PdfObjectString {
+	if isASCII(s) {
+		return core.MakeString(s)
+	}
+	return nil
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+var htmlTitlePattern = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+
+// htmlTitle gets the title of the HTML content, or the title of the index.html for the directory content.
+func (d *Document) htmlTitle() string {
+	if d.content == nil {
+		return ""
+	}
+	data := d.content.Data()
+	switch d.content.Method() {
+	case "html":
+	case "dir":
+		data = zipIndexHTML(data)
+	default:
+		return ""
+	}
+	match := htmlTitlePattern.FindSubmatch(data)
+	if match == nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(html.UnescapeString(string(match[1]))), " ")
+}
+
+func zipIndexHTML(data []byte) []byte {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil
+	}
+	for _, f := range zr.File {
+		if f.Name != "index.html" {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil
+		}
+		defer rc.Close()
+		buf := bytes.Buffer{}
+		if _, err = buf.ReadFrom(rc); err != nil {
+			return nil
+		}
+		return buf.Bytes()
+	}
+	return nil
+}
+
+// ===================== OUTPUT =====================
+
+// newCreator converts the document and creates the creator with its pages and the output setup.
+func (d *Document) newCreator(ctx context.Context) (*creator.Creator, error) {
+	reader, err := d.read(ctx, d.pageWidth, d.pageHeight, d.getMargins())
+	if err != nil {
+		return nil, err
+	}
+
+	if err = resolveNamedDests(reader); err != nil {
+		return nil, err
+	}
+	marks, err := d.watermarkBlocks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c := creator.New()
+	for i, p := range reader.PageList {
+		if err := c.AddPage(p); err != nil {
+			return nil, err
+		}
+		if len(marks) == 0 {
+			continue
+		}
+		box, err := p.GetMediaBox()
+		if err != nil {
+			return nil, err
+		}
+		for _, mark := range marks {
+			if err = mark.draw(c, i+1, box.Width(), box.Height()); err != nil {
+				return nil, err
+			}
+		}
+	}
+	var opts optimizers
+	if d.tagged {
+		opts = append(opts, NewStructTreeOptimizer(reader, d.language()))
+	}
+	if d.outline {
+		items, err := renderedOutline(reader)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, &outlineTree{items: items})
+	}
+	if d.letterhead != nil {
+		opts = append(opts, d.letterhead)
+	}
+	if len(opts) > 0 {
+		c.SetOptimizer(opts)
+	}
+
+	metadata := d.resolvedMetadata(time.Now())
+	makeString := makeTextString
+	if d.pdfa != 0 {
+		makeString = makePDFATextString
+	}
+	info, err := metadata.pdfInfo(makeString)
+	if err != nil {
+		return nil, err
+	}
+	xmp, err := metadata.xmpMetadata()
+	if err != nil {
+		return nil, err
+	}
+	c.SetPdfWriterAccessFunc(func(w *model.PdfWriter) error {
+		w.SetDocInfo(info)
+		// The PDF/A level sets the version of its own.
+		if d.tagged && d.pdfa == 0 {
+			w.SetVersion(1, 7)
+		}
+		if err := w.SetCatalogMetadata(xmp); err != nil {
+			return err
+		}
+		if d.pdfa != 0 {
+			if err := d.pdfa.Apply(w); err != nil {
+				return err
+			}
+		}
+		if d.encryption != nil {
+			return d.encryption.Apply(w)
+		}
+		return nil
+	})
+	return c, nil
+}
+
+// validateOutput checks if the document and its output options are valid, before the output is created.
+func (d *Document) validateOutput() error {
+	if err := d.validate(); err != nil {
+		return err
+	}
+	if d.signature != nil && d.encryption != nil {
+		return ErrSignEncrypted
+	}
+	if d.pdfa != 0 && d.encryption != nil {
+		return ErrPDFAEncrypted
+	}
+	return nil
+}
+
+// writePDF converts the document and writes it as a PDF, signed if the signature is set.
+func (d *Document) writePDF(ctx context.Context, w io.Writer) error {
+	c, err := d.newCreator(ctx)
+	if err != nil {
+		return err
+	}
+	if d.signature == nil {
+		return c.Write(w)
+	}
+
+	buf := bytes.Buffer{}
+	if err = c.Write(&buf); err != nil {
+		return err
+	}
+	return SignPDF(w, bytes.NewReader(buf.Bytes()), *d.signature)
+}
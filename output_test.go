@@ -0,0 +1,206 @@
+package gohtml
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/unitechio/gopdf/core"
+	"github.com/unitechio/gopdf/core/security"
+	"github.com/unitechio/gopdf/model"
+)
+
+// newTestOutput creates the single page document converted by the fake converter.
+func newTestOutput(t *testing.T) *Document {
+	t.Helper()
+	return newTestDocument(t, &fakeConverter{data: newTestPages(t, testPage{500, 700, 300})})
+}
+
+// catalogObject gets the resolved entry of the document catalog.
+func catalogObject(t *testing.T, reader *model.PdfReader, key core.PdfObjectName) core.PdfObject {
+	t.Helper()
+	trailer, err := reader.GetTrailer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	catalog, ok := core.GetDict(trailer.Get("Root"))
+	if !ok {
+		t.Fatal("expected the document catalog")
+	}
+	return core.ResolveReference(catalog.Get(key))
+}
+
+func TestWriteMetadata(t *testing.T) {
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	d := newTestOutput(t)
+	d.SetMetadata(Metadata{
+		Title:        "Zażółć",
+		Author:       "Author",
+		Keywords:     []string{"one", "two"},
+		CreationDate: created,
+	})
+	reader := readTestPDF(t, func(w *bytes.Buffer) error { return d.Write(w) })
+
+	info, err := reader.GetPdfInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range []struct {
+		name     string
+		value    *core.PdfObjectString
+		expected string
+	}{
+		{"title", info.Title, "Zażółć"},
+		{"author", info.Author, "Author"},
+		{"keywords", info.Keywords, "one, two"},
+	} {
+		if field.value == nil || field.value.Decoded() != field.expected {
+			t.Errorf("expected %s %q, got %v", field.name, field.expected, field.value)
+		}
+	}
+	if info.Producer == nil || !strings.HasPrefix(info.Producer.Decoded(), "UniHTML ") {
+		t.Errorf("expected the default producer, got %v", info.Producer)
+	}
+	if info.CreationDate == nil || !info.CreationDate.ToGoTime().Equal(created) {
+		t.Errorf("expected creation date %v, got %v", created, info.CreationDate)
+	}
+
+	stream, ok := core.GetStream(catalogObject(t, reader, "Metadata"))
+	if !ok {
+		t.Fatal("expected the XMP metadata stream")
+	}
+	xmp, err := core.DecodeStream(stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(xmp, []byte("Zażółć")) {
+		t.Errorf("expected the title in the XMP metadata, got %s", xmp)
+	}
+}
+
+func TestWriteEncryption(t *testing.T) {
+	testCases := []struct {
+		name      string
+		algorithm EncryptionAlgorithm
+		method    string
+	}{
+		{name: "AES256", algorithm: AES256, method: "AESV3"},
+		{name: "AES128", algorithm: AES128, method: "AESV2"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := newTestOutput(t)
+			err := d.SetEncryption(Encryption{
+				UserPassword:  "user",
+				OwnerPassword: "owner",
+				Algorithm:     tc.algorithm,
+				Deny:          PermissionPrint | PermissionCopy,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			reader := readTestPDF(t, func(w *bytes.Buffer) error { return d.Write(w) })
+
+			encrypted, err := reader.IsEncrypted()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !encrypted {
+				t.Fatal("expected the encrypted document")
+			}
+			if method := reader.GetEncryptionMethod(); !strings.Contains(method, tc.method) {
+				t.Errorf("expected encryption method %s, got %s", tc.method, method)
+			}
+			if ok, err := reader.Decrypt([]byte("wrong")); err != nil || ok {
+				t.Errorf("expected the wrong password rejected, got %t, %v", ok, err)
+			}
+			ok, perms, err := reader.CheckAccessRights([]byte("user"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !ok {
+				t.Fatal("expected the user password accepted")
+			}
+			for _, p := range []struct {
+				name     string
+				flag     security.Permissions
+				expected bool
+			}{
+				{"print", security.PermPrinting, false},
+				{"copy", security.PermExtractGraphics, false},
+				{"modify", security.PermModify, true},
+				{"annotate", security.PermAnnotate, true},
+			} {
+				if got := perms.Allowed(p.flag); got != p.expected {
+					t.Errorf("expected %s permission %t, got %t", p.name, p.expected, got)
+				}
+			}
+		})
+	}
+}
+
+func TestWritePDFA(t *testing.T) {
+	testCases := []struct {
+		name  string
+		level PDFA
+		part  string
+	}{
+		{name: "PDF/A-2B", level: PDFA2B, part: "2"},
+		{name: "PDF/A-1B", level: PDFA1B, part: "1"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := newTestOutput(t)
+			if err := d.SetPDFA(tc.level); err != nil {
+				t.Fatal(err)
+			}
+			d.SetMetadata(Metadata{Title: "Zażółć", Author: "Author"})
+			buf := bytes.Buffer{}
+			if err := d.Write(&buf); err != nil {
+				t.Fatal(err)
+			}
+			reader, err := model.NewPdfReader(bytes.NewReader(buf.Bytes()))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			intents, ok := core.GetArray(catalogObject(t, reader, "OutputIntents"))
+			if !ok || intents.Len() != 1 {
+				t.Fatalf("expected the single output intent, got %v", intents)
+			}
+			intent, ok := core.GetDict(intents.Get(0))
+			if !ok {
+				t.Fatal("expected the output intent dictionary")
+			}
+			if s, _ := core.GetNameVal(intent.Get("S")); s != "GTS_PDFA1" {
+				t.Errorf("expected output intent subtype GTS_PDFA1, got %s", s)
+			}
+			if _, ok := core.GetStream(intent.Get("DestOutputProfile")); !ok {
+				t.Error("expected the output intent ICC profile")
+			}
+
+			stream, ok := core.GetStream(catalogObject(t, reader, "Metadata"))
+			if !ok {
+				t.Fatal("expected the XMP metadata stream")
+			}
+			xmp, err := core.DecodeStream(stream)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, expected := range []string{"<pdfaid:part>" + tc.part + "</pdfaid:part>", "Zażółć", "Author", "UniHTML "} {
+				if !bytes.Contains(xmp, []byte(expected)) {
+					t.Errorf("expected %q in the XMP metadata, got %s", expected, xmp)
+				}
+			}
+
+			violations, err := ValidatePDFA(bytes.NewReader(buf.Bytes()), tc.level)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(violations) != 0 {
+				t.Errorf("expected no %s violations, got %v", tc.level, violations)
+			}
+		})
+	}
+}
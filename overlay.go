@@ -0,0 +1,259 @@
+package gohtml
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/unitechio/gohtml/sizes"
+	"github.com/unitechio/gopdf/contentstream"
+	"github.com/unitechio/gopdf/core"
+	"github.com/unitechio/gopdf/model"
+)
+
+// ErrOverlayEncrypted is returned when the overlaid document is encrypted.
+var ErrOverlayEncrypted = errors.New("encrypted documents can't be overlaid")
+
+// ErrOverlaySigned is returned when the overlaid document is signed, as the overlay would modify the signed pages.
+var ErrOverlaySigned = errors.New("signed documents can't be overlaid")
+
+// Overlay is the HTML document drawn over the page of the existing PDF document, i.e. the address or the table
+// filled in the form.
+type Overlay struct {
+	Document *Document
+
+	// Page is the number of the page starting from 1.
+	Page int
+
+	// X and Y are the position of the overlay top left corner, measured from the top left corner of the visible
+	// page box as the page is displayed, with its rotation.
+	X, Y sizes.Length
+
+	// Width is the width the document is rendered at. The height is the height of the rendered content,
+	// which has to fit a single page of the document.
+	Width sizes.Length
+}
+
+// Validate checks if the overlay options are valid.
+func (o Overlay) Validate() error {
+	if o.Document == nil {
+		return errors.New("overlay document not defined")
+	}
+	if o.Page < 1 {
+		return errors.New("provided invalid overlay page")
+	}
+	if o.X == nil || o.Y == nil {
+		return errors.New("overlay position not defined")
+	}
+	if o.Width == nil || o.Width.Points() <= 0 {
+		return errors.New("provided invalid overlay width")
+	}
+	return nil
+}
+
+// OverlayPDF renders the overlay documents and draws them over the pages of the PDF document read from the r.
+// The merged document is written into the w as the incremental update of the original, so that its content
+// and form fields are kept intact. The signed documents are refused with the ErrOverlaySigned, as their
+// signatures wouldn't cover the overlaid pages.
+func OverlayPDF(ctx context.Context, w io.Writer, r io.ReadSeeker, overlays ...Overlay) error {
+	for _, o := range overlays {
+		if err := o.Validate(); err != nil {
+			return err
+		}
+	}
+	reader, err := model.NewPdfReader(r)
+	if err != nil {
+		return err
+	}
+	if encrypted, err := reader.IsEncrypted(); err != nil {
+		return err
+	} else if encrypted {
+		return ErrOverlayEncrypted
+	}
+	if isSigned(reader) {
+		return ErrOverlaySigned
+	}
+	numPages, err := reader.GetNumPages()
+	if err != nil {
+		return err
+	}
+
+	pageOverlays := map[int][]Overlay{}
+	var pageNums []int
+	for _, o := range overlays {
+		if o.Page > numPages {
+			return fmt.Errorf("overlay page %d out of the document %d pages", o.Page, numPages)
+		}
+		if _, ok := pageOverlays[o.Page]; !ok {
+			pageNums = append(pageNums, o.Page)
+		}
+		pageOverlays[o.Page] = append(pageOverlays[o.Page], o)
+	}
+
+	appender, err := model.NewPdfAppender(reader)
+	if err != nil {
+		return err
+	}
+	for _, pageNum := range pageNums {
+		page, err := reader.GetPage(pageNum)
+		if err != nil {
+			return err
+		}
+		overlay, err := overlayPage(ctx, page, pageOverlays[pageNum])
+		if err != nil {
+			return err
+		}
+		// The page content is wrapped in the q/Q operators, so that the graphics state it leaves doesn't
+		// affect the overlay.
+		bracketed, err := bracketedPage(page)
+		if err != nil {
+			return err
+		}
+		appender.ReplacePage(pageNum, bracketed)
+		if err = appender.MergePageWith(pageNum, overlay); err != nil {
+			return err
+		}
+	}
+	return appender.Write(w)
+}
+
+// isSigned checks if the document has the signed signature fields or the signature permissions.
+func isSigned(reader *model.PdfReader) bool {
+	if perms := reader.GetPerms(); perms != nil && perms.DocMDP != nil {
+		return true
+	}
+	if reader.AcroForm == nil {
+		return false
+	}
+	for _, field := range reader.AcroForm.AllFields() {
+		if sig, ok := field.GetContext().(*model.PdfFieldSignature); ok && sig.V != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// bracketedPage duplicates the page with its content wrapped in the q/Q operators.
+func bracketedPage(page *model.PdfPage) (*model.PdfPage, error) {
+	cs, err := page.GetContentStreams()
+	if err != nil {
+		return nil, err
+	}
+	bracketed := page.Duplicate()
+	streams := append(append([]string{"q"}, cs...), "Q")
+	if err = bracketed.SetContentStreams(streams, core.NewFlateEncoder()); err != nil {
+		return nil, err
+	}
+	return bracketed, nil
+}
+
+// overlayPage creates the page of the size of the page, drawing the overlay documents as the form XObjects.
+func overlayPage(ctx context.Context, page *model.PdfPage, overlays []Overlay) (*model.PdfPage, error) {
+	box, err := page.GetMediaBox()
+	if err != nil {
+		return nil, err
+	}
+	// The position is measured from the visible part of the page.
+	visible := box
+	if page.CropBox != nil {
+		visible = page.CropBox
+	}
+	rotate, err := pageRotation(page)
+	if err != nil {
+		return nil, err
+	}
+
+	overlay := model.NewPdfPage()
+	overlay.MediaBox = box
+	cc := contentstream.NewContentCreator()
+	for i, o := range overlays {
+		content, err := o.Document.overlayContent(ctx, o.Width)
+		if err != nil {
+			return nil, err
+		}
+		form, err := pageForm(content)
+		if err != nil {
+			return nil, err
+		}
+		name := core.PdfObjectName(fmt.Sprintf("HTMLOverlay%d", i))
+		if err = overlay.Resources.SetXObjectFormByName(name, form); err != nil {
+			return nil, err
+		}
+
+		m := overlayMatrix(*visible, *content.MediaBox, rotate, float64(o.X.Points()), float64(o.Y.Points()))
+		cc.Add_q().Add_cm(m[0], m[1], m[2], m[3], m[4], m[5]).Add_Do(name).Add_Q()
+	}
+	if err = overlay.SetContentStreams([]string{cc.String()}, core.NewFlateEncoder()); err != nil {
+		return nil, err
+	}
+	return overlay, nil
+}
+
+// pageRotation gets the clockwise rotation of the displayed page in degrees, as 0, 90, 180 or 270.
+func pageRotation(page *model.PdfPage) (int64, error) {
+	var rotate int64
+	if page.Rotate != nil {
+		rotate = *page.Rotate
+	} else if parent := resolveDict(page.Parent); parent != nil {
+		if r, ok := core.GetIntVal(inheritedAttribute(parent, "Rotate")); ok {
+			rotate = int64(r)
+		}
+	}
+	if rotate%90 != 0 {
+		return 0, fmt.Errorf("provided invalid page rotation: %d", rotate)
+	}
+	return (rotate%360 + 360) % 360, nil
+}
+
+// overlayMatrix gets the transformation matrix moving the form of the content box to the x, y position measured
+// from the top left corner of the visible box, as the page is displayed rotated clockwise by the rotate degrees.
+func overlayMatrix(visible, cbox model.PdfRectangle, rotate int64, x, y float64) [6]float64 {
+	// The displayed page space is mapped to the page space by the inverse rotation, moved to the visible
+	// box corner that is displayed at the bottom left.
+	var r [6]float64
+	height := visible.Height()
+	switch rotate {
+	case 90:
+		r, height = [6]float64{0, 1, -1, 0, visible.Urx, visible.Lly}, visible.Width()
+	case 180:
+		r = [6]float64{-1, 0, 0, -1, visible.Urx, visible.Ury}
+	case 270:
+		r, height = [6]float64{0, -1, 1, 0, visible.Llx, visible.Ury}, visible.Width()
+	default:
+		r = [6]float64{1, 0, 0, 1, visible.Llx, visible.Lly}
+	}
+	// The form is moved from its box to the position in the displayed page space.
+	tx, ty := x-cbox.Llx, height-y-cbox.Ury
+	return [6]float64{r[0], r[1], r[2], r[3], r[0]*tx + r[2]*ty + r[4], r[1]*tx + r[3]*ty + r[5]}
+}
+
+// overlayContent renders the document at the width with no margins, and crops its page to the content height.
+func (d *Document) overlayContent(ctx context.Context, width sizes.Length) (*model.PdfPage, error) {
+	if err := d.validate(); err != nil {
+		return nil, err
+	}
+	reader, heights, err := d.renderComponent(ctx, width, d.componentPageHeight())
+	if err != nil {
+		return nil, err
+	}
+	if len(heights) == 0 {
+		return nil, ErrContentNotDefined
+	}
+	for _, h := range heights[1:] {
+		if h > 0 {
+			return nil, ErrFragmentTooTall
+		}
+	}
+	p := reader.PageList[0]
+	mbox, err := p.GetMediaBox()
+	if err != nil {
+		return nil, err
+	}
+	return cropPage(p, model.PdfRectangle{
+		Llx: mbox.Llx,
+		Lly: mbox.Ury - max(heights[0], 0),
+		Urx: mbox.Llx + float64(width.Points()),
+		Ury: mbox.Ury,
+	})
+}
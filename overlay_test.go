@@ -0,0 +1,273 @@
+package gohtml
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/unitechio/gohtml/sizes"
+	"github.com/unitechio/gopdf/contentstream"
+	"github.com/unitechio/gopdf/core"
+	"github.com/unitechio/gopdf/model"
+)
+
+func TestOverlayMatrix(t *testing.T) {
+	mbox := model.PdfRectangle{Urx: 600, Ury: 800}
+	cropped := model.PdfRectangle{Llx: 50, Lly: 100, Urx: 550, Ury: 750}
+	// The rendered content box of the 200x40 overlay.
+	cbox := model.PdfRectangle{Llx: 0, Lly: 760, Urx: 200, Ury: 800}
+
+	testCases := []struct {
+		name    string
+		visible model.PdfRectangle
+		cbox    model.PdfRectangle
+		rotate  int64
+		x, y    float64
+		// topLeft and bottomRight are the page space points the content box corners are drawn at.
+		topLeft, bottomRight [2]float64
+	}{
+		{
+			name:        "top left origin",
+			visible:     mbox,
+			cbox:        cbox,
+			x:           10,
+			y:           20,
+			topLeft:     [2]float64{10, 780},
+			bottomRight: [2]float64{210, 740},
+		},
+		{
+			name:        "crop box offset",
+			visible:     cropped,
+			cbox:        cbox,
+			x:           10,
+			y:           20,
+			topLeft:     [2]float64{60, 730},
+			bottomRight: [2]float64{260, 690},
+		},
+		{
+			name:        "content box offset",
+			visible:     mbox,
+			cbox:        model.PdfRectangle{Llx: 30, Lly: 60, Urx: 230, Ury: 100},
+			x:           10,
+			y:           20,
+			topLeft:     [2]float64{10, 780},
+			bottomRight: [2]float64{210, 740},
+		},
+		{
+			name:        "rotated 90",
+			visible:     cropped,
+			cbox:        cbox,
+			rotate:      90,
+			x:           10,
+			y:           20,
+			topLeft:     [2]float64{70, 110},
+			bottomRight: [2]float64{110, 310},
+		},
+		{
+			name:        "rotated 180",
+			visible:     cropped,
+			cbox:        cbox,
+			rotate:      180,
+			x:           10,
+			y:           20,
+			topLeft:     [2]float64{540, 120},
+			bottomRight: [2]float64{340, 160},
+		},
+		{
+			name:        "rotated 270",
+			visible:     cropped,
+			cbox:        cbox,
+			rotate:      270,
+			x:           10,
+			y:           20,
+			topLeft:     [2]float64{530, 740},
+			bottomRight: [2]float64{490, 540},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := overlayMatrix(tc.visible, tc.cbox, tc.rotate, tc.x, tc.y)
+			for _, p := range []struct {
+				x, y     float64
+				expected [2]float64
+			}{
+				{tc.cbox.Llx, tc.cbox.Ury, tc.topLeft},
+				{tc.cbox.Urx, tc.cbox.Lly, tc.bottomRight},
+			} {
+				x := m[0]*p.x + m[2]*p.y + m[4]
+				y := m[1]*p.x + m[3]*p.y + m[5]
+				if math.Abs(x-p.expected[0]) > 1e-9 || math.Abs(y-p.expected[1]) > 1e-9 {
+					t.Errorf("expected content point (%g, %g) drawn at %v, got (%g, %g)", p.x, p.y, p.expected, x, y)
+				}
+			}
+		})
+	}
+}
+
+func TestPageRotation(t *testing.T) {
+	rotate := func(v int64) *int64 { return &v }
+	testCases := []struct {
+		name     string
+		rotate   *int64
+		parent   core.PdfObject
+		expected int64
+		err      bool
+	}{
+		{name: "not rotated", expected: 0},
+		{name: "rotated", rotate: rotate(90), expected: 90},
+		{name: "negative", rotate: rotate(-90), expected: 270},
+		{name: "full turn", rotate: rotate(450), expected: 90},
+		{name: "invalid", rotate: rotate(45), err: true},
+		{
+			name:     "inherited",
+			parent:   core.MakeIndirectObject(core.MakeDictMap(map[string]core.PdfObject{"Rotate": core.MakeInteger(180)})),
+			expected: 180,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			page := model.NewPdfPage()
+			page.Rotate = tc.rotate
+			page.Parent = tc.parent
+			got, err := pageRotation(page)
+			if tc.err {
+				if err == nil {
+					t.Fatal("expected the invalid rotation error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.expected {
+				t.Errorf("expected rotation %d, got %d", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestBracketedPage(t *testing.T) {
+	page := model.NewPdfPage()
+	if err := page.SetContentStreams([]string{"1 0 0 RG", "0 0 10 10 re S"}, core.NewRawEncoder()); err != nil {
+		t.Fatal(err)
+	}
+	bracketed, err := bracketedPage(page)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs, err := bracketed.GetAllContentStreams()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fields := strings.Fields(cs); fields[0] != "q" || fields[len(fields)-1] != "Q" {
+		t.Errorf("expected the content wrapped in q/Q, got %q", cs)
+	}
+
+	original, err := page.GetAllContentStreams()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.HasPrefix(strings.TrimSpace(original), "q") {
+		t.Errorf("expected the original page content unchanged, got %q", original)
+	}
+}
+
+func TestOverlayPDF(t *testing.T) {
+	// The target document with the second page displayed rotated.
+	w := model.NewPdfWriter()
+	for _, rotate := range []int64{0, 90} {
+		page := model.NewPdfPage()
+		page.MediaBox = &model.PdfRectangle{Urx: 600, Ury: 800}
+		if rotate != 0 {
+			page.Rotate = &rotate
+		}
+		if err := page.SetContentStreams([]string{"1 0 0 RG 0 0 600 800 re S"}, core.NewRawEncoder()); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.AddPage(page); err != nil {
+			t.Fatal(err)
+		}
+	}
+	input := writeTestPDF(t, &w)
+
+	d := newTestDocument(t, &fakeConverter{data: newTestPages(t, testPage{200, 800, 40})})
+	overlay := func(page int) Overlay {
+		return Overlay{Document: d, Page: page, X: sizes.Point(10), Y: sizes.Point(20), Width: sizes.Point(200)}
+	}
+	output := bytes.Buffer{}
+	if err := OverlayPDF(context.Background(), &output, bytes.NewReader(input), overlay(1), overlay(2)); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(output.Bytes(), input) {
+		t.Error("expected the overlaid document to be an incremental update of the input")
+	}
+	reader, err := model.NewPdfReader(bytes.NewReader(output.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name   string
+		rotate int64
+		// matrix places the 200x40 overlay content box at 10, 20 from the displayed top left corner.
+		matrix [6]float64
+	}{
+		{name: "page", matrix: [6]float64{1, 0, 0, 1, 10, -20}},
+		{name: "rotated page", rotate: 90, matrix: [6]float64{0, 1, -1, 0, 820, 10}},
+	}
+	for i, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			page, err := reader.GetPage(i + 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rotate, err := pageRotation(page); err != nil || rotate != tc.rotate {
+				t.Errorf("expected rotation %d, got %d, %v", tc.rotate, rotate, err)
+			}
+			form, typ := page.Resources.GetXObjectByName("HTMLOverlay0")
+			if typ != model.XObjectTypeForm {
+				t.Fatalf("expected the overlay form in the page resources, got %v", typ)
+			}
+			data, err := core.DecodeStream(form)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Contains(data, []byte("re")) {
+				t.Errorf("expected the overlay content in the form, got %s", data)
+			}
+
+			cs, err := page.GetAllContentStreams()
+			if err != nil {
+				t.Fatal(err)
+			}
+			ops, err := contentstream.NewContentStreamParser(cs).Parse()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var operands []string
+			var matrix []float64
+			for _, op := range *ops {
+				operands = append(operands, op.Operand)
+				if op.Operand == "cm" {
+					if matrix, err = core.GetNumbersAsFloat(op.Params); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+			expected := "q RG re S Q q cm Do Q"
+			if got := strings.Join(operands, " "); got != expected {
+				t.Errorf("expected the page content followed by the overlay %q, got %q", expected, got)
+			}
+			if len(matrix) != 6 {
+				t.Fatalf("expected the overlay matrix, got %v", matrix)
+			}
+			for j, v := range tc.matrix {
+				if math.Abs(matrix[j]-v) > 1e-6 {
+					t.Fatalf("expected overlay matrix %v, got %v", tc.matrix, matrix)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package gohtml
+
+import (
+	"errors"
+	"io"
+	"time"
+
+	"github.com/unitechio/gopdf/model"
+	"github.com/unitechio/gopdf/model/pdfa"
+)
+
+// ErrPDFAEncrypted is returned when the PDF/A document is encrypted, which the standard forbids.
+var ErrPDFAEncrypted = errors.New("PDF/A documents can't be encrypted")
+
+// PDFA is the PDF/A conformance level of the document output.
+type PDFA uint8
+
+// PDF/A conformance levels.
+const (
+	PDFA2B PDFA = iota + 1
+	PDFA2U
+	PDFA1B
+)
+
+// String gets the name of the conformance level, i.e. PDF/A-2B.
+func (p PDFA) String() string {
+	switch p {
+	case PDFA2B:
+		return "PDF/A-2B"
+	case PDFA2U:
+		return "PDF/A-2U"
+	case PDFA1B:
+		return "PDF/A-1B"
+	}
+	return "undefined"
+}
+
+// profile gets the gopdf PDF/A profile of the conformance level.
+func (p PDFA) profile(now time.Time) (pdfa.Profile, error) {
+	nowFunc := func() time.Time { return now }
+	switch p {
+	case PDFA2B, PDFA2U:
+		opts := pdfa.DefaultProfile2Options()
+		opts.Now = nowFunc
+		opts.Xmp.NewDocumentVersion = true
+		if p == PDFA2U {
+			return pdfa.NewProfile2U(opts), nil
+		}
+		return pdfa.NewProfile2B(opts), nil
+	case PDFA1B:
+		opts := pdfa.DefaultProfile1Options()
+		opts.Now = nowFunc
+		opts.Xmp.NewDocumentVersion = true
+		return pdfa.NewProfile1B(opts), nil
+	}
+	return nil, errors.New("provided invalid PDF/A conformance level")
+}
+
+// Apply converts the output of the PDF writer to the PDF/A conformance level. It embeds the fonts,
+// adds the sRGB output intent and the PDF/A XMP metadata, and removes the content forbidden by the standard.
+func (p PDFA) Apply(w *model.PdfWriter) error {
+	profile, err := p.profile(time.Now())
+	if err != nil {
+		return err
+	}
+	w.ApplyStandard(profile)
+	return nil
+}
+
+// SetPDFA sets the PDF/A conformance level of the output.
+func (d *Document) SetPDFA(level PDFA) error {
+	if _, err := level.profile(time.Time{}); err != nil {
+		return err
+	}
+	d.pdfa = level
+	return nil
+}
+
+// PDFAViolation is the PDF/A rule the document doesn't conform to.
+type PDFAViolation struct {
+	// Rule is the number of the rule in the standard, i.e. 6.2.11.4.1.
+	Rule string
+
+	// Detail is the reason of the non-conformance.
+	Detail string
+}
+
+// ValidatePDFA checks if the PDF document read from the r conforms to the PDF/A level.
+// It returns the violated rules, or none if the document is conformant.
+func ValidatePDFA(r io.ReadSeeker, level PDFA) ([]PDFAViolation, error) {
+	profile, err := level.profile(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	reader, err := model.NewCompliancePdfReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	err = pdfa.Validate(reader, profile)
+	var verr pdfa.VerificationError
+	if !errors.As(err, &verr) {
+		return nil, err
+	}
+	violations := make([]PDFAViolation, len(verr.ViolatedRules))
+	for i, rule := range verr.ViolatedRules {
+		violations[i] = PDFAViolation{Rule: rule.RuleNo, Detail: rule.Detail}
+	}
+	return violations, nil
+}
@@ -0,0 +1,288 @@
+package gohtml
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"golang.org/x/crypto/pkcs12"
+
+	"github.com/unitechio/gohtml/sizes"
+	"github.com/unitechio/gopdf/annotator"
+	"github.com/unitechio/gopdf/core"
+	"github.com/unitechio/gopdf/model"
+	"github.com/unitechio/gopdf/model/sighandler"
+	"github.com/unitechio/gopdf/model/sigutil"
+)
+
+// ErrSignEncrypted is returned when the document is both encrypted and signed.
+var ErrSignEncrypted = errors.New("encrypted documents can't be signed")
+
+// defaultTimestampSize is the space reserved for the timestamp token in the document timestamp signature.
+const defaultTimestampSize = 8192
+
+// Signer is the private key and the certificate used to sign the PDF document.
+type Signer struct {
+	Key         *rsa.PrivateKey
+	Certificate *x509.Certificate
+}
+
+// NewSignerFromPKCS12 creates the signer from the PKCS#12 (.p12, .pfx) data protected with the password.
+func NewSignerFromPKCS12(data []byte, password string) (*Signer, error) {
+	key, cert, err := pkcs12.Decode(data, password)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("only RSA signing keys are supported")
+	}
+	return &Signer{Key: rsaKey, Certificate: cert}, nil
+}
+
+// NewSignerFromPEM creates the signer from the PEM encoded private key and certificate.
+// The key may be in the PKCS#1 or the PKCS#8 form.
+func NewSignerFromPEM(keyPEM, certPEM []byte) (*Signer, error) {
+	keyBlock, _ := pem.Decode(keyPEM)
+	if keyBlock == nil {
+		return nil, errors.New("private key PEM block not found")
+	}
+	certBlock, _ := pem.Decode(certPEM)
+	if certBlock == nil {
+		return nil, errors.New("certificate PEM block not found")
+	}
+	cert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	var key any
+	if key, err = x509.ParsePKCS1PrivateKey(keyBlock.Bytes); err != nil {
+		if key, err = x509.ParsePKCS8PrivateKey(keyBlock.Bytes); err != nil {
+			return nil, err
+		}
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("only RSA signing keys are supported")
+	}
+	return &Signer{Key: rsaKey, Certificate: cert}, nil
+}
+
+// SignatureField is the visible signature field placed on the page.
+type SignatureField struct {
+	// Page is the number of the page starting from 1. Zero places the field on the first page.
+	Page int
+
+	// X and Y are the position of the field top left corner, measured from the top left corner of the page.
+	X, Y sizes.Length
+
+	Width, Height sizes.Length
+}
+
+// TimestampClient executes the requests to the RFC 3161 timestamp authority. The *http.Client implements it,
+// and a stub may stand in for it in tests.
+type TimestampClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Timestamp is the timestamp authority adding the document timestamp to the signed PDF.
+type Timestamp struct {
+	// ServerURL is the URL of the timestamp authority.
+	ServerURL string
+
+	// Client executes the timestamp requests. Defaults to the HTTP client with the 5 second timeout.
+	Client TimestampClient
+}
+
+// Signature are the options of the digital signature of the PDF document.
+type Signature struct {
+	Signer Signer
+
+	// Name, Reason and Location describe the signature.
+	Name     string
+	Reason   string
+	Location string
+
+	// Field is the visible signature field. If nil, the signature is invisible.
+	Field *SignatureField
+
+	// Timestamp is the timestamp authority. If nil, the document is not timestamped.
+	Timestamp *Timestamp
+}
+
+// Validate checks if the signature options are valid.
+func (s Signature) Validate() error {
+	if s.Signer.Key == nil || s.Signer.Certificate == nil {
+		return errors.New("signature key and certificate not defined")
+	}
+	if f := s.Field; f != nil {
+		if f.Page < 0 {
+			return errors.New("provided invalid signature page")
+		}
+		if f.X == nil || f.Y == nil || f.Width == nil || f.Height == nil {
+			return errors.New("signature field position and size not defined")
+		}
+		if f.Width.Points() <= 0 || f.Height.Points() <= 0 {
+			return errors.New("provided invalid signature field size")
+		}
+	}
+	if s.Timestamp != nil && s.Timestamp.ServerURL == "" {
+		return errors.New("timestamp server URL not defined")
+	}
+	return nil
+}
+
+// SetSignature sets the digital signature of the output.
+func (d *Document) SetSignature(s Signature) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+	d.signature = &s
+	return nil
+}
+
+// SignPDF signs the PDF document read from the r and writes the signed document into the w.
+func SignPDF(w io.Writer, r io.ReadSeeker, s Signature) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+	reader, err := model.NewPdfReader(r)
+	if err != nil {
+		return err
+	}
+	if encrypted, err := reader.IsEncrypted(); err != nil {
+		return err
+	} else if encrypted {
+		return ErrSignEncrypted
+	}
+
+	handler, err := sighandler.NewAdobePKCS7Detached(s.Signer.Key, s.Signer.Certificate)
+	if err != nil {
+		return err
+	}
+	signature := model.NewPdfSignature(handler)
+	signature.SetName(s.Name)
+	signature.SetReason(s.Reason)
+	signature.SetLocation(s.Location)
+	signature.SetDate(time.Now(), "")
+	if err = signature.Initialize(); err != nil {
+		return err
+	}
+
+	pageNum, field, err := s.signatureField(reader, signature)
+	if err != nil {
+		return err
+	}
+	field.T = core.MakeString("Signature")
+
+	signed := bytes.Buffer{}
+	if err = appendSignature(reader, &signed, pageNum, field); err != nil {
+		return err
+	}
+	if s.Timestamp == nil {
+		_, err = w.Write(signed.Bytes())
+		return err
+	}
+	return s.Timestamp.apply(w, signed.Bytes())
+}
+
+// signatureField creates the visible or invisible signature field with its page number.
+func (s Signature) signatureField(reader *model.PdfReader, signature *model.PdfSignature) (int, *model.PdfFieldSignature, error) {
+	if s.Field == nil {
+		return 1, model.NewPdfFieldSignature(signature), nil
+	}
+
+	pageNum := max(s.Field.Page, 1)
+	page, err := reader.GetPage(pageNum)
+	if err != nil {
+		return 0, nil, err
+	}
+	box, err := page.GetMediaBox()
+	if err != nil {
+		return 0, nil, err
+	}
+	x := box.Llx + float64(s.Field.X.Points())
+	y := box.Ury - float64(s.Field.Y.Points()) - float64(s.Field.Height.Points())
+
+	opts := annotator.NewSignatureFieldOpts()
+	opts.Rect = []float64{x, y, x + float64(s.Field.Width.Points()), y + float64(s.Field.Height.Points())}
+	opts.AutoSize = true
+	var lines []*annotator.SignatureLine
+	for _, line := range [][2]string{
+		{"Signed by", s.Name},
+		{"Date", time.Now().Format(time.RFC1123)},
+		{"Reason", s.Reason},
+		{"Location", s.Location},
+	} {
+		if line[1] != "" {
+			lines = append(lines, annotator.NewSignatureLine(line[0], line[1]))
+		}
+	}
+	field, err := annotator.NewSignatureField(signature, lines, opts)
+	if err != nil {
+		return 0, nil, err
+	}
+	return pageNum, field, nil
+}
+
+// apply adds the document timestamp to the signed PDF data.
+func (t *Timestamp) apply(w io.Writer, data []byte) error {
+	client := sigutil.NewTimestampClient()
+	if t.Client != nil {
+		client.HTTPClient = &http.Client{Transport: timestampTransport{t.Client}}
+	}
+	handler, err := sighandler.NewDocTimeStampWithOpts(t.ServerURL, crypto.SHA512, &sighandler.DocTimeStampOpts{
+		SignatureSize: defaultTimestampSize,
+		Client:        client,
+	})
+	if err != nil {
+		return err
+	}
+
+	reader, err := model.NewPdfReader(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	signature := model.NewPdfSignature(handler)
+	if err = signature.Initialize(); err != nil {
+		return err
+	}
+	field := model.NewPdfFieldSignature(signature)
+	field.T = core.MakeString("Timestamp")
+
+	// The appender writes the document before the timestamp is requested, so that nothing is written
+	// into the w if the timestamp authority fails.
+	stamped := bytes.Buffer{}
+	if err = appendSignature(reader, &stamped, 1, field); err != nil {
+		return err
+	}
+	_, err = w.Write(stamped.Bytes())
+	return err
+}
+
+// timestampTransport routes the timestamp requests through the TimestampClient.
+type timestampTransport struct {
+	client TimestampClient
+}
+
+func (t timestampTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.client.Do(req)
+}
+
+func appendSignature(reader *model.PdfReader, w io.Writer, pageNum int, field *model.PdfFieldSignature) error {
+	appender, err := model.NewPdfAppender(reader)
+	if err != nil {
+		return err
+	}
+	if err = appender.Sign(pageNum, field); err != nil {
+		return err
+	}
+	return appender.Write(w)
+}
@@ -0,0 +1,169 @@
+package gohtml
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/unidoc/timestamp"
+	"github.com/unitechio/gopdf/creator"
+	"github.com/unitechio/gopdf/model"
+	"github.com/unitechio/gopdf/model/sighandler"
+)
+
+// newTestSigner creates the signer with the self-signed certificate.
+func newTestSigner(t *testing.T, usage x509.ExtKeyUsage) *Signer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{usage},
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Signer{Key: key, Certificate: cert}
+}
+
+// newTestPDF creates the single page PDF document.
+func newTestPDF(t *testing.T) []byte {
+	t.Helper()
+	c := creator.New()
+	c.NewPage()
+	p := c.NewStyledParagraph()
+	p.Append("Signed document")
+	if err := c.Draw(p); err != nil {
+		t.Fatal(err)
+	}
+	buf := bytes.Buffer{}
+	if err := c.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// newTestTSA starts the RFC 3161 timestamp authority stub, counting the received requests.
+func newTestTSA(t *testing.T, requests *int) *httptest.Server {
+	t.Helper()
+	tsa := newTestSigner(t, x509.ExtKeyUsageTimeStamping)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		req, err := timestamp.ParseRequest(body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		ts := timestamp.Timestamp{
+			HashAlgorithm:     req.HashAlgorithm,
+			HashedMessage:     req.HashedMessage,
+			Time:              time.Now(),
+			Nonce:             req.Nonce,
+			Policy:            []int{1, 2, 3},
+			AddTSACertificate: true,
+		}
+		resp, err := ts.CreateResponse(tsa.Certificate, tsa.Key)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/timestamp-reply")
+		w.Write(resp)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestSignPDFTimestamp(t *testing.T) {
+	var requests int
+	srv := newTestTSA(t, &requests)
+	signer := newTestSigner(t, x509.ExtKeyUsageAny)
+
+	input := newTestPDF(t)
+	output := bytes.Buffer{}
+	err := SignPDF(&output, bytes.NewReader(input), Signature{
+		Signer:    *signer,
+		Name:      "Test",
+		Timestamp: &Timestamp{ServerURL: srv.URL, Client: srv.Client()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requests == 0 {
+		t.Fatal("expected the timestamp authority request")
+	}
+	if !bytes.HasPrefix(output.Bytes(), input) {
+		t.Error("expected the signed document to be an incremental update of the input")
+	}
+
+	reader, err := model.NewPdfReader(bytes.NewReader(output.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkcs7, err := sighandler.NewAdobePKCS7Detached(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	docTimestamp, err := sighandler.NewDocTimeStamp("", crypto.SHA512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := reader.ValidateSignatures([]model.SignatureHandler{pkcs7, docTimestamp})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected the signature and the timestamp, got %d signatures", len(results))
+	}
+	for _, res := range results {
+		if !res.IsVerified {
+			t.Errorf("expected verified signature, got %s", res.String())
+		}
+	}
+}
+
+func TestSignPDFTimestampError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	signer := newTestSigner(t, x509.ExtKeyUsageAny)
+
+	output := bytes.Buffer{}
+	err := SignPDF(&output, bytes.NewReader(newTestPDF(t)), Signature{
+		Signer:    *signer,
+		Timestamp: &Timestamp{ServerURL: srv.URL, Client: srv.Client()},
+	})
+	if err == nil {
+		t.Fatal("expected the timestamp authority error")
+	}
+	if output.Len() != 0 {
+		t.Error("expected no output written on the timestamp error")
+	}
+}
@@ -0,0 +1,381 @@
+package gohtml
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/unitechio/gopdf/core"
+	"github.com/unitechio/gopdf/model"
+)
+
+// SetTagged enables the tagged PDF output with the structure tree built from the HTML semantics, i.e. headings,
+// paragraphs, lists, tables and figures with the alt text in the reading order. The tagging is required
+// by the accessibility standards like the PDF/UA. The document drawn by the creator is tagged as a single
+// structure element by the NewCreatorStructTreeOptimizer, see the SetMarkedContentID.
+func (d *Document) SetTagged(tagged bool) { d.tagged = tagged }
+
+// SetStructureType sets the structure type of the document element used when the document is drawn by the creator.
+// Defaults to the model.StructureTypeDivision.
+func (d *Document) SetStructureType(t model.StructureType) { d.structType = t }
+
+// SetMarkedContentID sets the first marked content identifier of the document element. The document content
+// drawn by the creator is marked on each of the pages it's drawn at, the parts drawn on the same page get
+// the subsequent identifiers. The Fragment block is not marked, as the page it's drawn at is not known.
+func (d *Document) SetMarkedContentID(id int64) { d.mcid = &id }
+
+// GenerateKDict generates the structure element of the document drawn by the creator. The element refers
+// to the marked content of the last layout, the page objects of the references are set once the element
+// is written by the NewCreatorStructTreeOptimizer.
+func (d *Document) GenerateKDict() (*model.KDict, error) {
+	return d.structElement(nil), nil
+}
+
+// structElement gets the structure element of the document with the marked content references
+// to the written pages. The references to the pages out of the written ones are dropped.
+func (d *Document) structElement(pages []core.PdfObject) *model.KDict {
+	k := &model.KDict{S: core.MakeName(string(d.structureType()))}
+	if lang := d.language(); lang != "" {
+		k.Lang = core.MakeString(lang)
+	}
+	if len(d.marked) == 0 {
+		return k
+	}
+	refs := core.MakeArray()
+	for _, m := range d.marked {
+		mcr := core.MakeDictMap(map[string]core.PdfObject{
+			"Type": core.MakeName("MCR"),
+			"MCID": core.MakeInteger(m.mcid),
+		})
+		if pages != nil {
+			if m.page < 1 || m.page > len(pages) {
+				continue
+			}
+			mcr.Set("Pg", pages[m.page-1])
+		}
+		refs.Append(mcr)
+	}
+	k.K = refs
+	return k
+}
+
+func (d *Document) structureType() model.StructureType {
+	if d.structType == model.StructureTypeUnknown {
+		return model.StructureTypeDivision
+	}
+	return d.structType
+}
+
+// markedContent is the marked content sequence of the document drawn on the creator page.
+type markedContent struct {
+	// page is the creator page number starting from 1.
+	page int
+	mcid int64
+}
+
+// markContent gets a copy of the page with its content wrapped in the marked content sequence of
+// the document structure element, drawn on the creator page. If the marked content identifier is not set
+// the page is returned as it is.
+func (d *Document) markContent(p *model.PdfPage, page int) (*model.PdfPage, error) {
+	if d.mcid == nil {
+		return p, nil
+	}
+	mcid := *d.mcid
+	for _, m := range d.marked {
+		if m.page == page {
+			mcid = max(mcid, m.mcid+1)
+		}
+	}
+	cs, err := p.GetAllContentStreams()
+	if err != nil {
+		return nil, err
+	}
+	tag := core.MakeName(string(d.structureType())).WriteString()
+	bdc := fmt.Sprintf("%s <</MCID %d>> BDC\n", tag, mcid)
+	cp := p.Duplicate()
+	if err = cp.SetContentStreams([]string{bdc + cs + "\nEMC\n"}, core.NewFlateEncoder()); err != nil {
+		return nil, err
+	}
+	d.marked = append(d.marked, markedContent{page: page, mcid: mcid})
+	return cp, nil
+}
+
+var htmlLangPattern = regexp.MustCompile(`(?is)<html[^>]*\slang\s*=\s*["']?([^"'\s>]+)`)
+
+// language gets the natural language of the document, either the locale or the HTML lang attribute.
+func (d *Document) language() string {
+	if d.locale != "" {
+		return d.locale
+	}
+	if d.content == nil {
+		return ""
+	}
+	data := d.content.Data()
+	switch d.content.Method() {
+	case "html":
+	case "dir":
+		data = zipIndexHTML(data)
+	default:
+		return ""
+	}
+	if match := htmlLangPattern.FindSubmatch(data); match != nil {
+		return string(match[1])
+	}
+	return ""
+}
+
+// NewStructTreeOptimizer creates the PDF writer optimizer that carries the structure tree of the tagged PDF
+// read by the reader into the written document. The reader pages must be written in the same order,
+// before any other pages. The lang is the natural language of the document, if known.
+func NewStructTreeOptimizer(reader *model.PdfReader, lang string) model.Optimizer {
+	root, _ := reader.GetCatalogStructTreeRoot()
+	t := &structTree{root: root, lang: lang}
+	for _, p := range reader.PageList {
+		page := sourcePage{number: objectNumber(p.GetContainingPdfObject())}
+		annotations, _ := p.GetAnnotations()
+		for _, a := range annotations {
+			page.annotations = append(page.annotations, objectNumber(a.GetContainingPdfObject()))
+		}
+		t.pages = append(t.pages, page)
+	}
+	return t
+}
+
+// NewCreatorStructTreeOptimizer creates the PDF writer optimizer that writes the structure tree of the documents
+// drawn by the creator with the marked content identifier set, see the SetMarkedContentID. Each document is
+// a structure element of the document root, referring to its marked content on the pages it's drawn at.
+// The creator pages are expected in the drawing order, i.e. with no front page and table of contents.
+func NewCreatorStructTreeOptimizer(docs ...*Document) model.Optimizer {
+	return &creatorStructTree{docs: docs}
+}
+
+// creatorStructTree is the optimizer writing the structure tree of the documents drawn by the creator.
+type creatorStructTree struct {
+	docs []*Document
+}
+
+// Optimize implements the model.Optimizer interface.
+func (t *creatorStructTree) Optimize(objects []core.PdfObject) ([]core.PdfObject, error) {
+	catalog := findCatalog(objects)
+	if catalog == nil {
+		return objects, nil
+	}
+	if catalog.Get("StructTreeRoot") != nil {
+		return nil, errors.New("creator output structure tree already written")
+	}
+
+	pages := writtenPages(catalog)
+	root := core.MakeIndirectObject(core.MakeDict())
+	doc := core.MakeIndirectObject(core.MakeDictMap(map[string]core.PdfObject{
+		"Type": core.MakeName("StructElem"),
+		"S":    core.MakeName(string(model.StructureTypeDocument)),
+		"P":    root,
+	}))
+	elements := core.MakeArray()
+
+	// parents are the structure elements of the written pages, indexed by their marked content identifiers.
+	parents := make([][]core.PdfObject, len(pages))
+	for _, d := range t.docs {
+		k := d.structElement(pages)
+		refs, ok := core.GetArray(k.K)
+		if !ok || refs.Len() == 0 {
+			continue
+		}
+		elem := core.MakeIndirectObject(core.MakeDictMap(map[string]core.PdfObject{
+			"Type": core.MakeName("StructElem"),
+			"S":    k.S,
+			"P":    doc,
+			"K":    refs,
+		}))
+		if k.Lang != nil {
+			elem.PdfObject.(*core.PdfObjectDictionary).Set("Lang", k.Lang)
+		}
+		elements.Append(elem)
+		for _, m := range d.marked {
+			if m.page < 1 || m.page > len(pages) {
+				continue
+			}
+			i := m.page - 1
+			for int64(len(parents[i])) <= m.mcid {
+				parents[i] = append(parents[i], core.MakeNull())
+			}
+			parents[i][m.mcid] = elem
+		}
+	}
+	if elements.Len() == 0 {
+		return objects, nil
+	}
+	doc.PdfObject.(*core.PdfObjectDictionary).Set("K", elements)
+
+	nums := core.MakeArray()
+	var key int64
+	for i, elems := range parents {
+		page := resolveDict(pages[i])
+		if len(elems) == 0 || page == nil {
+			continue
+		}
+		page.Set("StructParents", core.MakeInteger(key))
+		nums.Append(core.MakeInteger(key), core.MakeArray(elems...))
+		key++
+	}
+	root.PdfObject = core.MakeDictMap(map[string]core.PdfObject{
+		"Type":              core.MakeName("StructTreeRoot"),
+		"K":                 doc,
+		"ParentTree":        core.MakeDictMap(map[string]core.PdfObject{"Nums": nums}),
+		"ParentTreeNextKey": core.MakeInteger(key),
+	})
+	catalog.Set("StructTreeRoot", root)
+	catalog.Set("MarkInfo", core.MakeDictMap(map[string]core.PdfObject{"Marked": core.MakeBool(true)}))
+	return appendReferenced(objects, root), nil
+}
+
+// sourcePage are the object numbers of the page and its annotations in the tagged PDF.
+type sourcePage struct {
+	number      int64
+	annotations []int64
+}
+
+// structTree is the optimizer copying the structure tree into the written document catalog.
+type structTree struct {
+	root  core.PdfObject
+	lang  string
+	pages []sourcePage
+
+	// objects maps the source object numbers to the written page and annotation objects.
+	objects map[int64]core.PdfObject
+	// copies are the indirect objects of the copied structure tree by their source object numbers.
+	copies map[int64]core.PdfObject
+	added  []core.PdfObject
+}
+
+// Optimize implements the model.Optimizer interface.
+func (t *structTree) Optimize(objects []core.PdfObject) ([]core.PdfObject, error) {
+	if t.root == nil {
+		return objects, nil
+	}
+	catalog := findCatalog(objects)
+	if catalog == nil {
+		return objects, nil
+	}
+
+	t.objects = map[int64]core.PdfObject{}
+	t.copies = map[int64]core.PdfObject{}
+	for i, kid := range writtenPages(catalog) {
+		if i >= len(t.pages) {
+			break
+		}
+		t.objects[t.pages[i].number] = kid
+		dict := resolveDict(kid)
+		if dict == nil {
+			continue
+		}
+		annots, _ := core.GetArray(core.ResolveReference(dict.Get("Annots")))
+		for j, num := range t.pages[i].annotations {
+			if annots != nil && j < annots.Len() {
+				t.objects[num] = annots.Get(j)
+			}
+		}
+	}
+
+	catalog.Set("StructTreeRoot", t.copy(t.root))
+	catalog.Set("MarkInfo", core.MakeDictMap(map[string]core.PdfObject{"Marked": core.MakeBool(true)}))
+	if t.lang != "" {
+		catalog.Set("Lang", core.MakeString(t.lang))
+	}
+	prefs := resolveDict(catalog.Get("ViewerPreferences"))
+	if prefs == nil {
+		prefs = core.MakeDict()
+		catalog.Set("ViewerPreferences", prefs)
+	}
+	prefs.Set("DisplayDocTitle", core.MakeBool(true))
+	return append(objects, t.added...), nil
+}
+
+// copy deep copies the structure tree object. The page references are replaced with the written pages.
+func (t *structTree) copy(obj core.PdfObject) core.PdfObject {
+	obj = core.ResolveReference(obj)
+	switch o := obj.(type) {
+	case *core.PdfIndirectObject:
+		num := o.ObjectNumber
+		if written, ok := t.objects[num]; ok {
+			return written
+		}
+		if c, ok := t.copies[num]; ok {
+			return c
+		}
+		if dict, ok := o.PdfObject.(*core.PdfObjectDictionary); ok && isPageObject(dict) {
+			// Pages that weren't written are dropped from the structure tree.
+			return core.MakeNull()
+		}
+		c := core.MakeIndirectObject(core.MakeNull())
+		t.copies[num] = c
+		t.added = append(t.added, c)
+		c.PdfObject = t.copy(o.PdfObject)
+		return c
+	case *core.PdfObjectStream:
+		if c, ok := t.copies[o.ObjectNumber]; ok {
+			return c
+		}
+		c := &core.PdfObjectStream{Stream: o.Stream}
+		t.copies[o.ObjectNumber] = c
+		t.added = append(t.added, c)
+		c.PdfObjectDictionary, _ = core.GetDict(t.copy(o.PdfObjectDictionary))
+		return c
+	case *core.PdfObjectDictionary:
+		c := core.MakeDict()
+		for _, key := range o.Keys() {
+			c.Set(key, t.copy(o.Get(key)))
+		}
+		return c
+	case *core.PdfObjectArray:
+		c := core.MakeArray()
+		for _, el := range o.Elements() {
+			c.Append(t.copy(el))
+		}
+		return c
+	}
+	return obj
+}
+
+func findCatalog(objects []core.PdfObject) *core.PdfObjectDictionary {
+	for _, obj := range objects {
+		dict := resolveDict(obj)
+		if dict == nil {
+			continue
+		}
+		if name, ok := core.GetName(dict.Get("Type")); ok && *name == "Catalog" {
+			return dict
+		}
+	}
+	return nil
+}
+
+// writtenPages gets the page objects of the written document in the order.
+func writtenPages(catalog *core.PdfObjectDictionary) []core.PdfObject {
+	pages := resolveDict(catalog.Get("Pages"))
+	if pages == nil {
+		return nil
+	}
+	kids, ok := core.GetArray(core.ResolveReference(pages.Get("Kids")))
+	if !ok {
+		return nil
+	}
+	return kids.Elements()
+}
+
+func isPageObject(dict *core.PdfObjectDictionary) bool {
+	name, ok := core.GetName(dict.Get("Type"))
+	return ok && *name == "Page"
+}
+
+func resolveDict(obj core.PdfObject) *core.PdfObjectDictionary {
+	dict, _ := core.GetDict(core.ResolveReference(obj))
+	return dict
+}
+
+func objectNumber(obj core.PdfObject) int64 {
+	if ind, ok := core.GetIndirect(obj); ok {
+		return ind.ObjectNumber
+	}
+	return 0
+}
@@ -0,0 +1,117 @@
+package gohtml
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/unitechio/gohtml/content"
+	"github.com/unitechio/gopdf/core"
+	"github.com/unitechio/gopdf/model"
+)
+
+func TestGenerateKDict(t *testing.T) {
+	testCases := []struct {
+		name       string
+		html       string
+		structType model.StructureType
+		expectS    string
+		expectLang string
+	}{
+		{
+			name:    "default structure type",
+			html:    "<p>text</p>",
+			expectS: "Div",
+		},
+		{
+			name:       "structure type and language",
+			html:       `<html lang="vi"><p>text</p></html>`,
+			structType: model.StructureTypeArticle,
+			expectS:    "Art",
+			expectLang: "vi",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := content.NewStringContent(tc.html)
+			if err != nil {
+				t.Fatal(err)
+			}
+			d := &Document{content: c}
+			if tc.structType != model.StructureTypeUnknown {
+				d.SetStructureType(tc.structType)
+			}
+			k, err := d.GenerateKDict()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if name, ok := core.GetName(k.S); !ok || string(*name) != tc.expectS {
+				t.Errorf("expected structure type %s, got %v", tc.expectS, k.S)
+			}
+			if k.K != nil {
+				t.Errorf("expected no marked content before the layout, got %v", k.K)
+			}
+			lang := ""
+			if k.Lang != nil {
+				lang = k.Lang.Str()
+			}
+			if lang != tc.expectLang {
+				t.Errorf("expected lang %q, got %q", tc.expectLang, lang)
+			}
+		})
+	}
+}
+
+func TestMarkContent(t *testing.T) {
+	page := model.NewPdfPage()
+	page.MediaBox = &model.PdfRectangle{Urx: 100, Ury: 100}
+	if err := page.SetContentStreams([]string{"0 0 10 10 re f"}, core.NewRawEncoder()); err != nil {
+		t.Fatal(err)
+	}
+
+	d := &Document{}
+	unmarked, err := d.markContent(page, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unmarked != page {
+		t.Error("expected the page without the marked content identifier to be kept")
+	}
+
+	d.SetStructureType(model.StructureTypeFigure)
+	d.SetMarkedContentID(3)
+	var marked []*model.PdfPage
+	for _, pageNum := range []int{1, 1, 2} {
+		p, err := d.markContent(page, pageNum)
+		if err != nil {
+			t.Fatal(err)
+		}
+		marked = append(marked, p)
+	}
+	for i, expected := range []int64{3, 4, 3} {
+		cs, err := marked[i].GetAllContentStreams()
+		if err != nil {
+			t.Fatal(err)
+		}
+		cs = strings.TrimSpace(cs)
+		prefix := fmt.Sprintf("/Figure <</MCID %d>> BDC", expected)
+		if !strings.HasPrefix(cs, prefix) || !strings.HasSuffix(cs, "EMC") || !strings.Contains(cs, "0 0 10 10 re f") {
+			t.Errorf("expected the content marked with %q, got %q", prefix, cs)
+		}
+	}
+	if orig, _ := page.GetAllContentStreams(); strings.Contains(orig, "BDC") {
+		t.Error("the source page content was modified")
+	}
+
+	k, err := d.GenerateKDict()
+	if err != nil {
+		t.Fatal(err)
+	}
+	refs, ok := core.GetArray(k.K)
+	if !ok || refs.Len() != 3 {
+		t.Fatalf("expected the marked content references, got %v", k.K)
+	}
+	if mcid, _ := core.GetIntVal(resolveDict(refs.Get(1)).Get("MCID")); mcid != 4 {
+		t.Errorf("expected the second reference MCID 4, got %d", mcid)
+	}
+}
@@ -0,0 +1,247 @@
+package gohtml
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"math"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/unitechio/gohtml/common"
+)
+
+// ===================== TEMPLATE DOCUMENT =====================
+
+// NewDocumentFromTemplate creates a new Document from the template with provided 'name' executed with the data.
+// The template needs to have the TemplateFuncs defined if it uses any of them.
+func NewDocumentFromTemplate(tmpl *template.Template, name string, data any, opts ...DocumentOption) (*Document, error) {
+	if tmpl == nil {
+		return nil, errors.New("provided nil template")
+	}
+	html, err := executeTemplate(tmpl, name, data, nil)
+	if err != nil {
+		return nil, err
+	}
+	return NewDocumentFromString(html, opts...)
+}
+
+// NewDocumentFromTemplateFiles parses the template files matching the glob patterns with the TemplateFuncs
+// and creates a new Document from the template with provided 'name' executed with the data.
+func NewDocumentFromTemplateFiles(name string, data any, patterns []string, opts ...DocumentOption) (*Document, error) {
+	var files []string
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, matches...)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no template files matches the patterns: %v", patterns)
+	}
+
+	// Parsed templates are named by the base file name, keep their paths for error reporting.
+	// The files with the same base name would silently replace each other's templates.
+	paths := make(map[string]string, len(files))
+	for _, f := range files {
+		base := filepath.Base(f)
+		if prev, ok := paths[base]; ok && prev != f {
+			return nil, fmt.Errorf("template files %s and %s have the same name %q", prev, f, base)
+		}
+		paths[base] = f
+	}
+
+	tmpl, err := template.New(name).Funcs(TemplateFuncs()).ParseFiles(files...)
+	if err != nil {
+		return nil, newTemplateError(err, paths)
+	}
+	html, err := executeTemplate(tmpl, name, data, paths)
+	if err != nil {
+		return nil, err
+	}
+	return NewDocumentFromString(html, opts...)
+}
+
+func executeTemplate(tmpl *template.Template, name string, data any, paths map[string]string) (string, error) {
+	buf := bytes.Buffer{}
+	if err := tmpl.ExecuteTemplate(&buf, name, data); err != nil {
+		return "", newTemplateError(err, paths)
+	}
+	return buf.String(), nil
+}
+
+// ===================== TEMPLATE ERROR =====================
+
+// TemplateError is an error that occurred during template parsing or execution, with the location it refers to.
+type TemplateError struct {
+	File string
+	Line int
+	Err  error
+}
+
+// Error implements error interface.
+func (e *TemplateError) Error() string {
+	if e.File == "" {
+		return e.Err.Error()
+	}
+	if e.Line == 0 {
+		return fmt.Sprintf("%s: %v", e.File, e.Err)
+	}
+	return fmt.Sprintf("%s:%d: %v", e.File, e.Line, e.Err)
+}
+
+// Unwrap gets the underlying template error.
+func (e *TemplateError) Unwrap() error { return e.Err }
+
+// templateErrLocation matches the location of the text/template parse and execution errors,
+// which don't expose it in their fields.
+var templateErrLocation = regexp.MustCompile(`^template: ([^:]+):(\d+):`)
+
+func newTemplateError(err error, paths map[string]string) error {
+	tErr := &TemplateError{Err: err}
+	var escapeErr *template.Error
+	if errors.As(err, &escapeErr) {
+		// The html/template escaping errors carry their location, the line is zero if it's not known.
+		tErr.File, tErr.Line = escapeErr.Name, escapeErr.Line
+	} else if match := templateErrLocation.FindStringSubmatch(err.Error()); match != nil {
+		tErr.File = match[1]
+		tErr.Line, _ = strconv.Atoi(match[2])
+	}
+	if path, ok := paths[tErr.File]; ok {
+		tErr.File = path
+	}
+	return tErr
+}
+
+// ===================== TEMPLATE FUNCTIONS =====================
+
+// TemplateFuncs gets the default template functions used for the print documents:
+//
+//	number    - {{ number 2 .Value }} formats the number with the decimals and thousands separators: 1,234.57
+//	currency  - {{ currency "USD" .Total }} formats the amount in the currency: $1,234.50, 1,234,500 ₫
+//	date      - {{ date "date" .IssuedAt }} formats the time with the named layout (date, datetime, iso) or a Go layout
+//	utc       - {{ utc .IssuedAt }} formats the time converted to UTC like common.UtcTimeFormat
+//	pageBreak - {{ pageBreak }} inserts a page break
+//	avoidBreak - <tr style="{{ avoidBreak }}"> avoids a page break inside the element
+func TemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"number":     formatNumber,
+		"currency":   formatCurrency,
+		"date":       formatDate,
+		"utc":        formatUTC,
+		"pageBreak":  func() template.HTML { return `<div style="break-after: page; page-break-after: always;"></div>` },
+		"avoidBreak": func() template.CSS { return "break-inside: avoid; page-break-inside: avoid;" },
+	}
+}
+
+type currencyFormat struct {
+	symbol   string
+	decimals int
+	suffix   bool
+}
+
+var currencies = map[string]currencyFormat{
+	"USD": {symbol: "$", decimals: 2},
+	"EUR": {symbol: "€", decimals: 2},
+	"GBP": {symbol: "£", decimals: 2},
+	"JPY": {symbol: "¥", decimals: 0},
+	"VND": {symbol: "₫", decimals: 0, suffix: true},
+}
+
+func formatCurrency(code string, amount any) (string, error) {
+	v, err := toFloat(amount)
+	if err != nil {
+		return "", err
+	}
+	cf, ok := currencies[strings.ToUpper(code)]
+	if !ok {
+		return groupThousands(v, 2) + " " + code, nil
+	}
+	num := groupThousands(math.Abs(v), cf.decimals)
+	sign := ""
+	if v < 0 {
+		sign = "-"
+	}
+	if cf.suffix {
+		return sign + num + " " + cf.symbol, nil
+	}
+	return sign + cf.symbol + num, nil
+}
+
+func formatNumber(decimals int, value any) (string, error) {
+	v, err := toFloat(value)
+	if err != nil {
+		return "", err
+	}
+	return groupThousands(v, decimals), nil
+}
+
+var dateLayouts = map[string]string{
+	"date":     common.DateLayout,
+	"datetime": common.DateTimeLayout,
+	"iso":      common.ISODateLayout,
+}
+
+func formatDate(layout string, t time.Time) string {
+	if named, ok := dateLayouts[layout]; ok {
+		layout = named
+	}
+	return t.Format(layout)
+}
+
+func formatUTC(t time.Time) string { return common.UtcTimeFormat(t.UTC()) }
+
+func groupThousands(v float64, decimals int) string {
+	str := strconv.FormatFloat(v, 'f', decimals, 64)
+	sign := ""
+	if strings.HasPrefix(str, "-") {
+		sign, str = "-", str[1:]
+	}
+	intPart, fracPart := str, ""
+	if i := strings.IndexByte(str, '.'); i >= 0 {
+		intPart, fracPart = str[:i], str[i:]
+	}
+
+	var sb strings.Builder
+	sb.WriteString(sign)
+	for i, r := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			sb.WriteRune(',')
+		}
+		sb.WriteRune(r)
+	}
+	sb.WriteString(fracPart)
+	return sb.String()
+}
+
+func toFloat(value any) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	case int:
+		return float64(v), nil
+	case int32:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case uint:
+		return float64(v), nil
+	case uint32:
+		return float64(v), nil
+	case uint64:
+		return float64(v), nil
+	case string:
+		return strconv.ParseFloat(v, 64)
+	case fmt.Stringer:
+		return strconv.ParseFloat(v.String(), 64)
+	default:
+		return 0, fmt.Errorf("invalid number type: %T", value)
+	}
+}
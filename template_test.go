@@ -0,0 +1,161 @@
+package gohtml
+
+import (
+	"errors"
+	"html/template"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTemplateFormatFuncs(t *testing.T) {
+	hanoi := time.FixedZone("ICT", 7*60*60)
+	issued := time.Date(2025, time.March, 9, 1, 20, 0, 0, hanoi)
+
+	testCases := []struct {
+		name string
+		tmpl string
+		data any
+		want string
+	}{
+		{name: "number", tmpl: `{{ number 2 . }}`, data: 1234.567, want: "1,234.57"},
+		{name: "number no decimals", tmpl: `{{ number 0 . }}`, data: 1234567, want: "1,234,567"},
+		{name: "number negative", tmpl: `{{ number 1 . }}`, data: -1234.25, want: "-1,234.2"},
+		{name: "number small", tmpl: `{{ number 2 . }}`, data: 12, want: "12.00"},
+		{name: "number string", tmpl: `{{ number 2 . }}`, data: "999999.5", want: "999,999.50"},
+		{name: "currency usd", tmpl: `{{ currency "USD" . }}`, data: 1234.5, want: "$1,234.50"},
+		{name: "currency lower case code", tmpl: `{{ currency "eur" . }}`, data: 10, want: "€10.00"},
+		{name: "currency negative", tmpl: `{{ currency "USD" . }}`, data: -5.5, want: "-$5.50"},
+		{name: "currency suffix", tmpl: `{{ currency "VND" . }}`, data: 1234500, want: "1,234,500 ₫"},
+		{name: "currency no decimals", tmpl: `{{ currency "JPY" . }}`, data: 1500.4, want: "¥1,500"},
+		{name: "currency unknown", tmpl: `{{ currency "CHF" . }}`, data: 1000, want: "1,000.00 CHF"},
+		{name: "date", tmpl: `{{ date "date" . }}`, data: issued, want: "9 March 2025"},
+		{name: "datetime", tmpl: `{{ date "datetime" . }}`, data: issued, want: "9 March 2025 at 01:20"},
+		{name: "iso", tmpl: `{{ date "iso" . }}`, data: issued, want: "2025-03-09"},
+		{name: "go layout", tmpl: `{{ date "02/01/06" . }}`, data: issued, want: "09/03/25"},
+		{name: "utc converts local time", tmpl: `{{ utc . }}`, data: issued, want: "8 March 2025 at 18:20 UTC"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpl := template.Must(template.New("t").Funcs(TemplateFuncs()).Parse(tc.tmpl))
+			var sb strings.Builder
+			if err := tmpl.Execute(&sb, tc.data); err != nil {
+				t.Fatalf("Execute() failed: %v", err)
+			}
+			if sb.String() != tc.want {
+				t.Errorf("got %q, want %q", sb.String(), tc.want)
+			}
+		})
+	}
+}
+
+func TestTemplateFormatFuncsInvalid(t *testing.T) {
+	for _, src := range []string{`{{ number 2 . }}`, `{{ currency "USD" . }}`} {
+		tmpl := template.Must(template.New("t").Funcs(TemplateFuncs()).Parse(src))
+		if err := tmpl.Execute(&strings.Builder{}, []int{1}); err == nil {
+			t.Errorf("%s: expected invalid number error", src)
+		}
+	}
+}
+
+func writeTemplateFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, src := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestTemplateError(t *testing.T) {
+	testCases := []struct {
+		name     string
+		files    map[string]string
+		data     any
+		wantFile string
+		wantLine int
+	}{
+		{
+			name:     "parse error",
+			files:    map[string]string{"page.html": "<p>\n{{ .Title }</p>"},
+			wantFile: "page.html",
+			wantLine: 2,
+		},
+		{
+			name:     "execution error",
+			files:    map[string]string{"page.html": "<p>\n\n{{ number 2 .Title }}</p>"},
+			data:     map[string]any{"Title": []int{1}},
+			wantFile: "page.html",
+			wantLine: 3,
+		},
+		{
+			name: "execution error in included template",
+			files: map[string]string{
+				"page.html":   `{{ template "header.html" . }}`,
+				"header.html": "<h1>\n{{ currency \"USD\" .Total }}</h1>",
+			},
+			data:     map[string]any{"Total": "abc"},
+			wantFile: "header.html",
+			wantLine: 2,
+		},
+		{
+			name:     "escaping error",
+			files:    map[string]string{"page.html": "<p>\n<a href=\"{{ .URL }}></p>"},
+			data:     map[string]any{"URL": "x"},
+			wantFile: "page.html",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := writeTemplateFiles(t, tc.files)
+			_, err := NewDocumentFromTemplateFiles("page.html", tc.data, []string{filepath.Join(dir, "*.html")})
+			var tErr *TemplateError
+			if !errors.As(err, &tErr) {
+				t.Fatalf("expected TemplateError, got %v", err)
+			}
+			if want := filepath.Join(dir, tc.wantFile); tErr.File != want {
+				t.Errorf("File = %q, want %q", tErr.File, want)
+			}
+			if tErr.Line != tc.wantLine {
+				t.Errorf("Line = %d, want %d (%v)", tErr.Line, tc.wantLine, tErr.Err)
+			}
+		})
+	}
+}
+
+func TestNewDocumentFromTemplateFiles(t *testing.T) {
+	dir := writeTemplateFiles(t, map[string]string{
+		"page.html":         `{{ template "header.html" . }}<p>{{ .Body }}</p>`,
+		"a/header.html":     `<h1>{{ .Title }}</h1>`,
+		"b/header.html":     `<h2>{{ .Title }}</h2>`,
+		"parts/footer.html": `<footer></footer>`,
+	})
+	data := map[string]any{"Title": "T", "Body": "B"}
+
+	_, err := NewDocumentFromTemplateFiles("page.html", data,
+		[]string{filepath.Join(dir, "*.html"), filepath.Join(dir, "a", "*.html")})
+	if err != nil {
+		t.Errorf("unique names failed: %v", err)
+	}
+
+	_, err = NewDocumentFromTemplateFiles("page.html", data,
+		[]string{filepath.Join(dir, "*.html"), filepath.Join(dir, "*", "*.html")})
+	if err == nil || !strings.Contains(err.Error(), "header.html") {
+		t.Errorf("duplicate names error = %v, want the header.html duplicate", err)
+	}
+
+	_, err = NewDocumentFromTemplateFiles("page.html", data, []string{filepath.Join(dir, "missing", "*.html")})
+	if err == nil {
+		t.Error("expected no matching files error")
+	}
+}
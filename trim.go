@@ -0,0 +1,273 @@
+package gohtml
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"time"
+
+	"github.com/unitechio/gopdf/common"
+	"github.com/unitechio/gopdf/model"
+	"github.com/unitechio/gopdf/render"
+)
+
+// TrimSide is a set of the page sides trimmed off the empty space.
+type TrimSide uint8
+
+// Page sides to trim.
+const (
+	TrimBottom TrimSide = 1 << iota
+	TrimTop
+	TrimLeft
+	TrimRight
+
+	TrimAllSides = TrimBottom | TrimTop | TrimLeft | TrimRight
+)
+
+// TrimOptions are the options of trimming the empty space off the rendered document pages.
+type TrimOptions struct {
+	// Tolerance is the maximum difference (0-1) of each of the RGB channels from the background color
+	// for the pixel to be treated as an empty space. Zero matches the background color exactly, a small
+	// tolerance, i.e. 0.03, trims the anti-aliasing noise along with the near-white content.
+	Tolerance float64
+
+	// Background is the color of the empty space. If not set the bottom left pixel of the page is used.
+	Background color.Color
+
+	// Sides are the page sides to trim. Defaults to the TrimBottom.
+	Sides TrimSide
+
+	// AllPages trims all the document pages, not only the last one.
+	AllPages bool
+
+	// RenderWidth is the width in pixels of the page render used for detecting the empty space.
+	// Smaller widths are faster to scan but less precise. Zero renders the page at its full size.
+	RenderWidth int
+
+	// exactWhite matches the white background exactly, applying the tolerance to other colors only.
+	exactWhite bool
+}
+
+// DefaultTrimTolerance is the tolerance used by the TrimLastPageContent for the background colors other than white.
+const DefaultTrimTolerance = 0.03
+
+func (o TrimOptions) withDefaults() TrimOptions {
+	o.Tolerance = max(o.Tolerance, 0)
+	if o.Sides == 0 {
+		o.Sides = TrimBottom
+	}
+	return o
+}
+
+// SetTrimOptions sets up trimming the empty space off the document pages.
+func (d *Document) SetTrimOptions(o TrimOptions) {
+	o = o.withDefaults()
+	d.trim = &o
+}
+
+// TrimLastPageContent trims the empty space at the bottom of the last document page. The white background
+// is matched exactly, other background colors within the DefaultTrimTolerance. The exact match of any
+// background color is set up by the SetTrimOptions with zero Tolerance.
+func (d *Document) TrimLastPageContent() {
+	d.SetTrimOptions(TrimOptions{Tolerance: DefaultTrimTolerance, exactWhite: true})
+}
+
+func (d *Document) trimOptions() TrimOptions {
+	if d.trim != nil {
+		return *d.trim
+	}
+	return TrimOptions{}.withDefaults()
+}
+
+// trimPage checks if the page at index 'i' of 'n' document pages needs to be trimmed.
+func (d *Document) trimPage(i, n int) bool {
+	return d.trim != nil && (d.trim.AllPages || i == n-1)
+}
+
+// trimContent crops the empty space off the trimmed page sides, keeping the document margins.
+func (d *Document) trimContent(p *model.PdfPage) (*model.PdfPage, error) {
+	opts := d.trimOptions()
+	box, err := measureContentBox(p, opts)
+	if err != nil {
+		return nil, err
+	}
+	mbox, err := p.GetMediaBox()
+	if err != nil {
+		return nil, err
+	}
+	if opts.Sides&TrimBottom != 0 && d.margins.Bottom != nil {
+		box.Lly = math.Max(mbox.Lly, box.Lly-float64(d.margins.Bottom.Points()))
+	}
+	if opts.Sides&TrimTop != 0 && d.margins.Top != nil {
+		box.Ury = math.Min(mbox.Ury, box.Ury+float64(d.margins.Top.Points()))
+	}
+	if opts.Sides&TrimLeft != 0 && d.margins.Left != nil {
+		box.Llx = math.Max(mbox.Llx, box.Llx-float64(d.margins.Left.Points()))
+	}
+	if opts.Sides&TrimRight != 0 && d.margins.Right != nil {
+		box.Urx = math.Min(mbox.Urx, box.Urx+float64(d.margins.Right.Points()))
+	}
+	common.Log.Trace("Cropping document's page to the content box: %+v", box)
+	return cropPage(p, box)
+}
+
+// measureContentBox renders the page and gets the box of its content in the page coordinates,
+// with the sides that are not trimmed at the media box.
+func measureContentBox(p *model.PdfPage, opts TrimOptions) (model.PdfRectangle, error) {
+	mbox, err := p.GetMediaBox()
+	if err != nil {
+		return model.PdfRectangle{}, err
+	}
+	start := time.Now()
+	dev := render.NewImageDevice()
+	dev.OutputWidth = opts.RenderWidth
+	img, err := dev.Render(p)
+	if err != nil {
+		return model.PdfRectangle{}, err
+	}
+	bounds := img.Bounds()
+	content := detectContentBounds(img, opts)
+	common.Log.Trace("Measuring page content box taken: %v", time.Since(start))
+	if content.Empty() {
+		return model.PdfRectangle{Llx: mbox.Llx, Lly: mbox.Ury, Urx: mbox.Urx, Ury: mbox.Ury}, nil
+	}
+
+	sx := mbox.Width() / float64(bounds.Dx())
+	sy := mbox.Height() / float64(bounds.Dy())
+	return model.PdfRectangle{
+		Llx: mbox.Llx + float64(content.Min.X-bounds.Min.X)*sx,
+		Lly: mbox.Ury - float64(content.Max.Y-bounds.Min.Y)*sy,
+		Urx: mbox.Llx + float64(content.Max.X-bounds.Min.X)*sx,
+		Ury: mbox.Ury - float64(content.Min.Y-bounds.Min.Y)*sy,
+	}, nil
+}
+
+// measureTrimHeight gets the height of the empty space at the bottom of the page.
+func measureTrimHeight(p *model.PdfPage, opts TrimOptions) (float64, error) {
+	opts.Sides = TrimBottom
+	box, err := measureContentBox(p, opts)
+	if err != nil {
+		return 0, err
+	}
+	mbox, err := p.GetMediaBox()
+	if err != nil {
+		return 0, err
+	}
+	return box.Lly - mbox.Lly, nil
+}
+
+// detectContentBounds gets the bounds of the image pixels that differs from the background color on
+// the trimmed sides. The sides that are not trimmed are kept at the image bounds. If the image has
+// no content the function returns an empty rectangle.
+func detectContentBounds(img image.Image, opts TrimOptions) image.Rectangle {
+	rgba, ok := img.(*image.RGBA)
+	if !ok {
+		rgba = image.NewRGBA(img.Bounds())
+		draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+	}
+	bounds := rgba.Bounds()
+	if bounds.Empty() {
+		return image.Rectangle{}
+	}
+
+	ref := opts.Background
+	if ref == nil {
+		ref = rgba.RGBAAt(bounds.Min.X, bounds.Max.Y-1)
+	}
+	tolerance := opts.Tolerance
+	if opts.exactWhite && isWhite(ref) {
+		tolerance = 0
+	}
+	s := newPixelScanner(rgba, ref, tolerance)
+
+	content := bounds
+	if opts.Sides&TrimBottom != 0 {
+		for content.Max.Y > content.Min.Y && s.emptyRow(content.Max.Y-1, content.Min.X, content.Max.X) {
+			content.Max.Y--
+		}
+		if content.Max.Y == content.Min.Y {
+			return image.Rectangle{}
+		}
+	}
+	if opts.Sides&TrimTop != 0 {
+		for content.Min.Y < content.Max.Y && s.emptyRow(content.Min.Y, content.Min.X, content.Max.X) {
+			content.Min.Y++
+		}
+		if content.Max.Y == content.Min.Y {
+			return image.Rectangle{}
+		}
+	}
+	if opts.Sides&TrimLeft != 0 {
+		for content.Min.X < content.Max.X && s.emptyColumn(content.Min.X, content.Min.Y, content.Max.Y) {
+			content.Min.X++
+		}
+	}
+	if opts.Sides&TrimRight != 0 {
+		for content.Max.X > content.Min.X && s.emptyColumn(content.Max.X-1, content.Min.Y, content.Max.Y) {
+			content.Max.X--
+		}
+	}
+	if content.Empty() {
+		return image.Rectangle{}
+	}
+	return content
+}
+
+// pixelScanner checks the pixels of the image against the background color,
+// reading the image pixels slice directly.
+type pixelScanner struct {
+	img       *image.RGBA
+	r, g, b   int
+	tolerance int
+}
+
+func newPixelScanner(img *image.RGBA, ref color.Color, tolerance float64) *pixelScanner {
+	c := color.RGBAModel.Convert(ref).(color.RGBA)
+	return &pixelScanner{
+		img:       img,
+		r:         int(c.R),
+		g:         int(c.G),
+		b:         int(c.B),
+		tolerance: int(math.Round(tolerance * math.MaxUint8)),
+	}
+}
+
+func (s *pixelScanner) emptyRow(y, minX, maxX int) bool {
+	start := s.img.PixOffset(minX, y)
+	end := s.img.PixOffset(maxX, y)
+	for i := start; i < end; i += 4 {
+		if !s.background(i) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *pixelScanner) emptyColumn(x, minY, maxY int) bool {
+	for i, end := s.img.PixOffset(x, minY), s.img.PixOffset(x, maxY); i < end; i += s.img.Stride {
+		if !s.background(i) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *pixelScanner) background(i int) bool {
+	pix := s.img.Pix[i : i+3 : i+3]
+	return abs(int(pix[0])-s.r) <= s.tolerance &&
+		abs(int(pix[1])-s.g) <= s.tolerance &&
+		abs(int(pix[2])-s.b) <= s.tolerance
+}
+
+func isWhite(c color.Color) bool {
+	r, g, b, _ := c.RGBA()
+	return r == math.MaxUint16 && g == math.MaxUint16 && b == math.MaxUint16
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
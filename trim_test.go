@@ -0,0 +1,271 @@
+package gohtml
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/unitechio/gopdf/contentstream"
+	"github.com/unitechio/gopdf/core"
+	"github.com/unitechio/gopdf/model"
+)
+
+// fill is the rectangle of the test image filled with the color.
+type fill struct {
+	rect  image.Rectangle
+	color color.RGBA
+}
+
+// newTestImage creates the white image with the rectangles filled with the colors in order.
+func newTestImage(w, h int, fills []fill) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	for _, f := range fills {
+		for y := f.rect.Min.Y; y < f.rect.Max.Y; y++ {
+			for x := f.rect.Min.X; x < f.rect.Max.X; x++ {
+				img.SetRGBA(x, y, f.color)
+			}
+		}
+	}
+	return img
+}
+
+func TestDetectContentBounds(t *testing.T) {
+	black := color.RGBA{A: 0xff}
+	nearWhite := color.RGBA{R: 0xfa, G: 0xfa, B: 0xfa, A: 0xff}
+	gray := color.RGBA{R: 0xf0, G: 0xf0, B: 0xf0, A: 0xff}
+
+	testCases := []struct {
+		name     string
+		fills    []fill
+		opts     TrimOptions
+		expected image.Rectangle
+	}{
+		{
+			name:     "bottom",
+			fills:    []fill{{image.Rect(10, 20, 30, 40), black}},
+			opts:     TrimOptions{Sides: TrimBottom},
+			expected: image.Rect(0, 0, 100, 40),
+		},
+		{
+			name:     "all sides",
+			fills:    []fill{{image.Rect(10, 20, 30, 40), black}},
+			opts:     TrimOptions{Sides: TrimAllSides},
+			expected: image.Rect(10, 20, 30, 40),
+		},
+		{
+			name:     "top and left",
+			fills:    []fill{{image.Rect(10, 20, 30, 40), black}},
+			opts:     TrimOptions{Sides: TrimTop | TrimLeft},
+			expected: image.Rect(10, 20, 100, 100),
+		},
+		{
+			name:     "empty",
+			opts:     TrimOptions{Sides: TrimAllSides},
+			expected: image.Rectangle{},
+		},
+		{
+			name:     "near white kept by the exact match",
+			fills:    []fill{{image.Rect(0, 50, 100, 60), nearWhite}},
+			opts:     TrimOptions{Sides: TrimBottom},
+			expected: image.Rect(0, 0, 100, 60),
+		},
+		{
+			name:     "near white trimmed within the tolerance",
+			fills:    []fill{{image.Rect(0, 50, 100, 60), nearWhite}},
+			opts:     TrimOptions{Sides: TrimBottom, Tolerance: 0.03},
+			expected: image.Rectangle{},
+		},
+		{
+			name: "gray out of the tolerance",
+			fills: []fill{
+				{image.Rect(0, 50, 100, 60), gray},
+				{image.Rect(0, 70, 100, 80), nearWhite},
+			},
+			opts:     TrimOptions{Sides: TrimBottom, Tolerance: 0.03},
+			expected: image.Rect(0, 0, 100, 60),
+		},
+		{
+			name: "background color",
+			fills: []fill{
+				{image.Rect(0, 0, 100, 100), gray},
+				{image.Rect(40, 40, 60, 60), black},
+			},
+			opts:     TrimOptions{Sides: TrimAllSides, Background: gray},
+			expected: image.Rect(40, 40, 60, 60),
+		},
+		{
+			name: "background of the bottom left pixel",
+			fills: []fill{
+				{image.Rect(0, 0, 100, 100), gray},
+				{image.Rect(40, 40, 60, 60), black},
+			},
+			opts:     TrimOptions{Sides: TrimBottom | TrimRight},
+			expected: image.Rect(0, 0, 60, 60),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			img := newTestImage(100, 100, tc.fills)
+			if got := detectContentBounds(img, tc.opts.withDefaults()); got != tc.expected {
+				t.Errorf("expected content bounds %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestTrimLastPageContent(t *testing.T) {
+	gray := color.RGBA{R: 0xf0, G: 0xf0, B: 0xf0, A: 0xff}
+	// The anti-aliasing noise within the 3% tolerance of the background colors.
+	nearGray := color.RGBA{R: 0xf4, G: 0xee, B: 0xf0, A: 0xff}
+	nearWhite := color.RGBA{R: 0xfa, G: 0xfa, B: 0xfa, A: 0xff}
+	black := color.RGBA{A: 0xff}
+
+	testCases := []struct {
+		name     string
+		fills    []fill
+		expected image.Rectangle
+	}{
+		{
+			name: "colored background noise trimmed",
+			fills: []fill{
+				{image.Rect(0, 0, 100, 100), gray},
+				{image.Rect(10, 20, 30, 40), black},
+				{image.Rect(0, 60, 100, 70), nearGray},
+			},
+			expected: image.Rect(0, 0, 100, 40),
+		},
+		{
+			name: "white background matched exactly",
+			fills: []fill{
+				{image.Rect(10, 20, 30, 40), black},
+				{image.Rect(0, 60, 100, 70), nearWhite},
+			},
+			expected: image.Rect(0, 0, 100, 70),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := &Document{}
+			d.TrimLastPageContent()
+			img := newTestImage(100, 100, tc.fills)
+			if got := detectContentBounds(img, d.trimOptions()); got != tc.expected {
+				t.Errorf("expected content bounds %v, got %v", tc.expected, got)
+			}
+		})
+	}
+
+	// The exact match is opt-in by the trim options.
+	d := &Document{}
+	d.SetTrimOptions(TrimOptions{})
+	img := newTestImage(100, 100, testCases[0].fills)
+	if got, expected := detectContentBounds(img, d.trimOptions()), image.Rect(0, 0, 100, 70); got != expected {
+		t.Errorf("expected exact match content bounds %v, got %v", expected, got)
+	}
+}
+
+func TestPixelScanner(t *testing.T) {
+	img := newTestImage(4, 2, []fill{
+		{image.Rect(1, 0, 2, 1), color.RGBA{R: 0xfc, G: 0xff, B: 0xff, A: 0xff}},
+		{image.Rect(3, 1, 4, 2), color.RGBA{R: 0xff, G: 0xff, B: 0xf0, A: 0xff}},
+	})
+	white := color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+
+	testCases := []struct {
+		name      string
+		tolerance float64
+		rows      [2]bool
+		columns   [4]bool
+	}{
+		{name: "exact", tolerance: 0, rows: [2]bool{false, false}, columns: [4]bool{true, false, true, false}},
+		{name: "tolerance", tolerance: 0.02, rows: [2]bool{true, false}, columns: [4]bool{true, true, true, false}},
+		{name: "wide tolerance", tolerance: 0.1, rows: [2]bool{true, true}, columns: [4]bool{true, true, true, true}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newPixelScanner(img, white, tc.tolerance)
+			for y, expected := range tc.rows {
+				if got := s.emptyRow(y, 0, 4); got != expected {
+					t.Errorf("expected row %d empty %t, got %t", y, expected, got)
+				}
+			}
+			for x, expected := range tc.columns {
+				if got := s.emptyColumn(x, 0, 2); got != expected {
+					t.Errorf("expected column %d empty %t, got %t", x, expected, got)
+				}
+			}
+		})
+	}
+}
+
+func TestMeasureContentBox(t *testing.T) {
+	testCases := []struct {
+		name     string
+		mbox     model.PdfRectangle
+		rect     model.PdfRectangle
+		sides    TrimSide
+		expected model.PdfRectangle
+	}{
+		{
+			name:     "bottom",
+			mbox:     model.PdfRectangle{Urx: 200, Ury: 300},
+			rect:     model.PdfRectangle{Llx: 50, Lly: 200, Urx: 100, Ury: 250},
+			sides:    TrimBottom,
+			expected: model.PdfRectangle{Llx: 0, Lly: 200, Urx: 200, Ury: 300},
+		},
+		{
+			name:     "all sides",
+			mbox:     model.PdfRectangle{Urx: 200, Ury: 300},
+			rect:     model.PdfRectangle{Llx: 50, Lly: 200, Urx: 100, Ury: 250},
+			sides:    TrimAllSides,
+			expected: model.PdfRectangle{Llx: 50, Lly: 200, Urx: 100, Ury: 250},
+		},
+		{
+			name:     "media box offset",
+			mbox:     model.PdfRectangle{Llx: 100, Lly: 50, Urx: 300, Ury: 350},
+			rect:     model.PdfRectangle{Llx: 150, Lly: 100, Urx: 200, Ury: 150},
+			sides:    TrimAllSides,
+			expected: model.PdfRectangle{Llx: 150, Lly: 100, Urx: 200, Ury: 150},
+		},
+		{
+			name:     "empty page",
+			mbox:     model.PdfRectangle{Urx: 200, Ury: 300},
+			sides:    TrimBottom,
+			expected: model.PdfRectangle{Llx: 0, Lly: 300, Urx: 200, Ury: 300},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			page := model.NewPdfPage()
+			page.MediaBox = &tc.mbox
+			cc := contentstream.NewContentCreator()
+			if tc.rect.Width() > 0 {
+				cc.Add_rg(0, 0, 0).
+					Add_re(tc.rect.Llx, tc.rect.Lly, tc.rect.Width(), tc.rect.Height()).
+					Add_f()
+			}
+			if err := page.SetContentStreams([]string{cc.String()}, core.NewRawEncoder()); err != nil {
+				t.Fatal(err)
+			}
+
+			box, err := measureContentBox(page, TrimOptions{Sides: tc.sides}.withDefaults())
+			if err != nil {
+				t.Fatal(err)
+			}
+			// The box is measured on the rendered pixels, one pixel per point.
+			for _, v := range [][2]float64{
+				{box.Llx, tc.expected.Llx},
+				{box.Lly, tc.expected.Lly},
+				{box.Urx, tc.expected.Urx},
+				{box.Ury, tc.expected.Ury},
+			} {
+				if math.Abs(v[0]-v[1]) > 1 {
+					t.Fatalf("expected content box %+v, got %+v", tc.expected, box)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,383 @@
+package gohtml
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+
+	"github.com/unitechio/gohtml/sizes"
+	"github.com/unitechio/gopdf/contentstream"
+	"github.com/unitechio/gopdf/core"
+	"github.com/unitechio/gopdf/creator"
+	"github.com/unitechio/gopdf/model"
+)
+
+// WatermarkPosition is the point of the page the watermark is anchored to.
+type WatermarkPosition uint8
+
+// Watermark positions.
+const (
+	WatermarkCenter WatermarkPosition = iota
+	WatermarkTopLeft
+	WatermarkTop
+	WatermarkTopRight
+	WatermarkLeft
+	WatermarkRight
+	WatermarkBottomLeft
+	WatermarkBottom
+	WatermarkBottomRight
+)
+
+// defaultWatermarkFontSize is the font size of the text watermark.
+const defaultWatermarkFontSize = 60
+
+// Watermark is the text, image or HTML stamp drawn over the document pages, i.e. "DRAFT" or "CONFIDENTIAL".
+// Exactly one of the Text, Image and HTML is required.
+type Watermark struct {
+	Text string
+
+	// Font is the font of the Text. Defaults to the Helvetica Bold.
+	Font *model.PdfFont
+
+	// FontSize is the font size of the Text in points. Defaults to 60.
+	FontSize float64
+
+	// Color is the color of the Text. Defaults to the gray.
+	Color creator.Color
+
+	Image image.Image
+
+	// HTML is the document rendered as the stamp cropped to its content, the same way as the Fragment.
+	// It is rendered once and drawn as the vector content on all of the pages. Unless set on the HTML document,
+	// the converter and the cache of the watermarked document are used.
+	HTML *Document
+
+	// Width is the width of the watermark, the Text and the Image are scaled to it. It is required for the HTML.
+	Width sizes.Length
+
+	// Angle is the counterclockwise rotation of the watermark in degrees around its center.
+	Angle float64
+
+	// Opacity is the opacity of the watermark from 0, which is fully transparent, to 1. If nil, the watermark
+	// is opaque.
+	Opacity *float64
+
+	// Position is the point of the page the rotated watermark is anchored to, at the page edges
+	// or in its center.
+	Position WatermarkPosition
+
+	// X and Y move the watermark from its position to the right and down.
+	X, Y sizes.Length
+
+	// Pages selects the pages the watermark is drawn on by their number starting from 1.
+	// If nil, the watermark is drawn on all pages.
+	Pages func(pageNum int) bool
+}
+
+// Validate checks if the watermark options are valid.
+func (w Watermark) Validate() error {
+	kinds := 0
+	for _, set := range []bool{w.Text != "", w.Image != nil, w.HTML != nil} {
+		if set {
+			kinds++
+		}
+	}
+	if kinds != 1 {
+		return errors.New("exactly one of the watermark text, image and html is required")
+	}
+	if w.HTML != nil && w.Width == nil {
+		return errors.New("html watermark width not defined")
+	}
+	if w.Width != nil && w.Width.Points() <= 0 {
+		return errors.New("provided invalid watermark width")
+	}
+	if w.FontSize < 0 {
+		return errors.New("provided invalid watermark font size")
+	}
+	if w.Opacity != nil && (*w.Opacity < 0 || *w.Opacity > 1) {
+		return errors.New("provided invalid watermark opacity")
+	}
+	if w.Position > WatermarkBottomRight {
+		return errors.New("provided invalid watermark position")
+	}
+	return nil
+}
+
+// AddWatermark adds the watermark drawn over the pages of the document, both written by the WriteToFile and
+// Write functions and drawn by the creator. Within the creator the pages are numbered as the creator pages.
+// The watermark is drawn on the creator pages the document is drawn at, both within a creator.Chapter and
+// within the containers like creator.Division. It's placed relative to the page, not to the document content.
+func (d *Document) AddWatermark(w Watermark) error {
+	if err := w.Validate(); err != nil {
+		return err
+	}
+	if w.HTML != nil {
+		w.HTML.watermarked = d
+	}
+	d.watermarks = append(d.watermarks, w)
+	d.marks = nil
+	return nil
+}
+
+// pageWatermark is the watermark block with its placement options.
+type pageWatermark struct {
+	Watermark
+	block *creator.Block
+}
+
+// watermarkBlocks gets the blocks of the document watermarks, created once for the document. The watermark
+// content is wrapped in the form XObject, so that its resources don't clash with the resources of the page
+// it's drawn on.
+func (d *Document) watermarkBlocks(ctx context.Context) ([]pageWatermark, error) {
+	if d.marks != nil || len(d.watermarks) == 0 {
+		return d.marks, nil
+	}
+	marks := make([]pageWatermark, len(d.watermarks))
+	for i, w := range d.watermarks {
+		page, err := w.page(ctx)
+		if err != nil {
+			return nil, err
+		}
+		block, err := formBlock(page, core.PdfObjectName(fmt.Sprintf("Watermark%d", i)), w.opacity())
+		if err != nil {
+			return nil, err
+		}
+		if w.Width != nil && w.HTML == nil {
+			block.ScaleToWidth(float64(w.Width.Points()))
+		}
+		block.SetAngle(w.Angle)
+		marks[i] = pageWatermark{Watermark: w, block: block}
+	}
+	d.marks = marks
+	return marks, nil
+}
+
+// opacity gets the opacity of the watermark, opaque by default.
+func (w Watermark) opacity() float64 {
+	if w.Opacity == nil {
+		return 1
+	}
+	return *w.Opacity
+}
+
+// page creates the page with the watermark content, sized to it.
+func (w Watermark) page(ctx context.Context) (*model.PdfPage, error) {
+	switch {
+	case w.HTML != nil:
+		_, page, err := w.HTML.fragmentPage(ctx, w.Width)
+		return page, err
+	case w.Image != nil:
+		return imageWatermarkPage(w.Image)
+	}
+	return w.textPage()
+}
+
+// textPage creates the page with the watermark text on a single line.
+func (w Watermark) textPage() (*model.PdfPage, error) {
+	font := w.Font
+	if font == nil {
+		var err error
+		if font, err = model.NewStandard14Font(model.HelveticaBoldName); err != nil {
+			return nil, err
+		}
+	}
+	size := w.FontSize
+	if size == 0 {
+		size = defaultWatermarkFontSize
+	}
+	var color creator.Color = creator.ColorRGBFrom8bit(128, 128, 128)
+	if w.Color != nil {
+		color = w.Color
+	}
+
+	var width float64
+	for _, r := range w.Text {
+		if metrics, ok := font.GetRuneMetrics(r); ok {
+			width += metrics.Wx * size / 1000
+		}
+	}
+	ascent, descent := fontExtents(font)
+	text, missing := font.StringToCharcodeBytes(w.Text)
+	if missing > 0 {
+		return nil, fmt.Errorf("watermark font can't encode %d characters of the text", missing)
+	}
+
+	page := model.NewPdfPage()
+	page.MediaBox = &model.PdfRectangle{Urx: width, Ury: (ascent - descent) * size}
+	if err := page.Resources.SetFontByName("F0", font.ToPdfObject()); err != nil {
+		return nil, err
+	}
+	cc := contentstream.NewContentCreator().
+		Add_BT().
+		Add_rg(color.ToRGB()).
+		Add_Tf("F0", size).
+		Add_Td(0, -descent*size).
+		Add_Tj(*core.MakeStringFromBytes(text)).
+		Add_ET()
+	if err := page.SetContentStreams([]string{cc.String()}, core.NewFlateEncoder()); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// fontExtents gets the font ascent and descent relative to the font size. The fonts with no
+// font descriptor get the extents of the Helvetica.
+func fontExtents(font *model.PdfFont) (float64, float64) {
+	ascent, descent := 0.718, -0.207
+	if fd, err := font.GetFontDescriptor(); err == nil && fd != nil {
+		if v, err := core.GetNumberAsFloat(fd.Ascent); err == nil && v != 0 {
+			ascent = v / 1000
+		}
+		if v, err := core.GetNumberAsFloat(fd.Descent); err == nil && v != 0 {
+			descent = v / 1000
+		}
+	}
+	return ascent, descent
+}
+
+// imageWatermarkPage creates the page with the image drawn at one point per pixel.
+func imageWatermarkPage(img image.Image) (*model.PdfPage, error) {
+	mimg, err := model.ImageHandling.NewImageFromGoImage(img)
+	if err != nil {
+		return nil, err
+	}
+	ximg, err := model.NewXObjectImageFromImage(mimg, nil, core.NewFlateEncoder())
+	if err != nil {
+		return nil, err
+	}
+	w, h := float64(mimg.Width), float64(mimg.Height)
+
+	page := model.NewPdfPage()
+	page.MediaBox = &model.PdfRectangle{Urx: w, Ury: h}
+	if err = page.Resources.SetXObjectImageByName("Im0", ximg); err != nil {
+		return nil, err
+	}
+	cc := contentstream.NewContentCreator().
+		Add_q().
+		Add_cm(w, 0, 0, h, 0, 0).
+		Add_Do("Im0").
+		Add_Q()
+	if err = page.SetContentStreams([]string{cc.String()}, core.NewFlateEncoder()); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// pageForm creates the form XObject with the content and the resources of the page, bounded by its media box.
+func pageForm(page *model.PdfPage) (*model.XObjectForm, error) {
+	box, err := page.GetMediaBox()
+	if err != nil {
+		return nil, err
+	}
+	cs, err := page.GetAllContentStreams()
+	if err != nil {
+		return nil, err
+	}
+	form := model.NewXObjectForm()
+	form.Resources = page.Resources
+	form.BBox = box.ToPdfObject()
+	if err = form.SetContentStream([]byte(cs), core.NewFlateEncoder()); err != nil {
+		return nil, err
+	}
+	return form, nil
+}
+
+// formBlock creates the block of the page size drawing the page content as the form XObject with the name.
+// The content is translucent if the opacity is less than 1.
+func formBlock(page *model.PdfPage, name core.PdfObjectName, opacity float64) (*creator.Block, error) {
+	box, err := page.GetMediaBox()
+	if err != nil {
+		return nil, err
+	}
+	form, err := pageForm(page)
+	if err != nil {
+		return nil, err
+	}
+
+	formPage := model.NewPdfPage()
+	formPage.MediaBox = box
+	if err = formPage.Resources.SetXObjectFormByName(name, form); err != nil {
+		return nil, err
+	}
+	cc := contentstream.NewContentCreator().Add_q()
+	if opacity < 1 {
+		gs := core.MakeDict()
+		gs.Set("Type", core.MakeName("ExtGState"))
+		gs.Set("ca", core.MakeFloat(opacity))
+		gs.Set("CA", core.MakeFloat(opacity))
+		gsName := "GS" + name
+		if err = formPage.Resources.AddExtGState(gsName, gs); err != nil {
+			return nil, err
+		}
+		cc.Add_gs(gsName)
+	}
+	cc.Add_Do(name).Add_Q()
+	if err = formPage.SetContentStreams([]string{cc.String()}, core.NewFlateEncoder()); err != nil {
+		return nil, err
+	}
+	return creator.NewBlockFromPage(formPage)
+}
+
+// pageDrawer is the page the watermark is drawn on, the creator.Creator with its active page or the page sized creator.Block.
+type pageDrawer interface {
+	Draw(d creator.Drawable) error
+}
+
+// draw draws the watermark on the page of the page number, if it's selected by the watermark pages.
+func (w pageWatermark) draw(page pageDrawer, pageNum int, pageWidth, pageHeight float64) error {
+	if w.Pages != nil && !w.Pages(pageNum) {
+		return nil
+	}
+	x, y := w.position(pageWidth, pageHeight)
+	w.block.SetPos(x, y)
+	return page.Draw(w.block)
+}
+
+// drawWatermarks draws the document watermarks on the page blocks.
+func (d *Document) drawWatermarks(ctx context.Context, pb pageBlocks) error {
+	if len(d.watermarks) == 0 {
+		return nil
+	}
+	marks, err := d.watermarkBlocks(ctx)
+	if err != nil {
+		return err
+	}
+	for i, b := range pb.blocks {
+		for _, mark := range marks {
+			if err = mark.draw(b, pb.start+i, b.Width(), b.Height()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// position gets the top left position of the unrotated watermark block on the page, so that the rotated
+// watermark is anchored to its position.
+func (w pageWatermark) position(pageWidth, pageHeight float64) (float64, float64) {
+	width, height := w.block.Width(), w.block.Height()
+	rw, rh := w.block.RotatedSize()
+
+	// The center of the rotated watermark, as the block is rotated around its center.
+	cx, cy := pageWidth/2, pageHeight/2
+	switch w.Position {
+	case WatermarkTopLeft, WatermarkLeft, WatermarkBottomLeft:
+		cx = rw / 2
+	case WatermarkTopRight, WatermarkRight, WatermarkBottomRight:
+		cx = pageWidth - rw/2
+	}
+	switch w.Position {
+	case WatermarkTopLeft, WatermarkTop, WatermarkTopRight:
+		cy = rh / 2
+	case WatermarkBottomLeft, WatermarkBottom, WatermarkBottomRight:
+		cy = pageHeight - rh/2
+	}
+	if w.X != nil {
+		cx += float64(w.X.Points())
+	}
+	if w.Y != nil {
+		cy += float64(w.Y.Points())
+	}
+	return cx - width/2, cy - height/2
+}
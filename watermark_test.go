@@ -0,0 +1,169 @@
+package gohtml
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/unitechio/gohtml/cache"
+	"github.com/unitechio/gohtml/sizes"
+	"github.com/unitechio/gopdf/core"
+	"github.com/unitechio/gopdf/creator"
+	"github.com/unitechio/gopdf/model"
+)
+
+func TestWatermarkPosition(t *testing.T) {
+	const pageWidth, pageHeight = 600, 800
+
+	testCases := []struct {
+		name     string
+		position WatermarkPosition
+		angle    float64
+		x, y     sizes.Length
+		expected [2]float64
+	}{
+		{name: "center", position: WatermarkCenter, expected: [2]float64{250, 390}},
+		{name: "top left", position: WatermarkTopLeft, expected: [2]float64{0, 0}},
+		{name: "top", position: WatermarkTop, expected: [2]float64{250, 0}},
+		{name: "top right", position: WatermarkTopRight, expected: [2]float64{500, 0}},
+		{name: "left", position: WatermarkLeft, expected: [2]float64{0, 390}},
+		{name: "right", position: WatermarkRight, expected: [2]float64{500, 390}},
+		{name: "bottom left", position: WatermarkBottomLeft, expected: [2]float64{0, 780}},
+		{name: "bottom", position: WatermarkBottom, expected: [2]float64{250, 780}},
+		{name: "bottom right", position: WatermarkBottomRight, expected: [2]float64{500, 780}},
+		{
+			name:     "offset",
+			position: WatermarkTopLeft,
+			x:        sizes.Point(10),
+			y:        sizes.Point(20),
+			expected: [2]float64{10, 20},
+		},
+		{name: "rotated top left", position: WatermarkTopLeft, angle: 90, expected: [2]float64{-40, 40}},
+		{name: "rotated bottom right", position: WatermarkBottomRight, angle: 90, expected: [2]float64{540, 740}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			block := creator.NewBlock(100, 20)
+			block.SetAngle(tc.angle)
+			w := pageWatermark{Watermark: Watermark{Position: tc.position, X: tc.x, Y: tc.y}, block: block}
+			x, y := w.position(pageWidth, pageHeight)
+			if math.Abs(x-tc.expected[0]) > 1e-9 || math.Abs(y-tc.expected[1]) > 1e-9 {
+				t.Errorf("expected position %v, got (%g, %g)", tc.expected, x, y)
+			}
+		})
+	}
+}
+
+func TestFormBlockOpacity(t *testing.T) {
+	opacity := func(v float64) *float64 { return &v }
+	testCases := []struct {
+		name    string
+		opacity *float64
+		gs      bool
+	}{
+		{name: "opaque by default"},
+		{name: "transparent", opacity: opacity(0), gs: true},
+		{name: "translucent", opacity: opacity(0.5), gs: true},
+		{name: "opaque", opacity: opacity(1)},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := Watermark{Text: "DRAFT", Opacity: tc.opacity}
+			page, err := w.textPage()
+			if err != nil {
+				t.Fatal(err)
+			}
+			block, err := formBlock(page, "Watermark0", w.opacity())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			c := creator.New()
+			c.NewPage()
+			if err = c.Draw(block); err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			if err = c.Write(&buf); err != nil {
+				t.Fatal(err)
+			}
+			reader, err := model.NewPdfReader(bytes.NewReader(buf.Bytes()))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var opacities []float64
+			if states, ok := core.GetDict(reader.PageList[0].Resources.ExtGState); ok {
+				for _, key := range states.Keys() {
+					gs, ok := core.GetDict(states.Get(key))
+					if !ok {
+						continue
+					}
+					for _, name := range []core.PdfObjectName{"ca", "CA"} {
+						if v, err := core.GetNumberAsFloat(gs.Get(name)); err == nil {
+							opacities = append(opacities, v)
+						}
+					}
+				}
+			}
+			if !tc.gs {
+				if len(opacities) != 0 {
+					t.Errorf("expected no opacity graphics state, got %v", opacities)
+				}
+				return
+			}
+			if len(opacities) != 2 || opacities[0] != *tc.opacity || opacities[1] != *tc.opacity {
+				t.Errorf("expected the fill and stroke opacity %g, got %v", *tc.opacity, opacities)
+			}
+		})
+	}
+}
+
+func TestWatermarkConverter(t *testing.T) {
+	data := newTestPages(t, testPage{500, 700, 300})
+	newDocuments := func(conv, markConv Converter, c cache.Cache) *Document {
+		d, err := NewDocumentFromString(`<p>text</p>`, WithConverter(conv), WithCache(c))
+		if err != nil {
+			t.Fatal(err)
+		}
+		mark, err := NewDocumentFromString(`<p>DRAFT</p>`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if markConv != nil {
+			mark.SetConverter(markConv)
+		}
+		if err = d.AddWatermark(Watermark{HTML: mark, Width: sizes.Point(200)}); err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	write := func(d *Document) {
+		if err := d.Write(&bytes.Buffer{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The watermark is converted by the converter of the watermarked document.
+	c := cache.NewMemory(10)
+	conv := &fakeConverter{data: data}
+	write(newDocuments(conv, nil, c))
+	if conv.calls != 2 {
+		t.Errorf("expected the document and the watermark conversions, got %d", conv.calls)
+	}
+
+	// The watermark render is stored in the cache of the watermarked document.
+	conv = &fakeConverter{data: data}
+	write(newDocuments(conv, nil, c))
+	if conv.calls != 0 {
+		t.Errorf("expected the cached document and watermark, got %d conversions", conv.calls)
+	}
+
+	// The converter set on the watermark document is kept.
+	conv, markConv := &fakeConverter{data: data}, &fakeConverter{data: data}
+	write(newDocuments(conv, markConv, nil))
+	if conv.calls != 1 || markConv.calls != 1 {
+		t.Errorf("expected the document and the watermark converted by their converters, got %d and %d conversions",
+			conv.calls, markConv.calls)
+	}
+}